@@ -0,0 +1,232 @@
+package nodefertest
+
+import (
+	"go/ast"
+	"go/types"
+	"slices"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// isAllowedDefer checks if the defer is exempt from reporting
+func isAllowedDefer(pass *analysis.Pass, opts *Options, ignored map[int]bool, deferStmt *ast.DeferStmt) bool {
+	// Skip defers suppressed by an ignore directive
+	if ignored[pass.Fset.PositionFor(deferStmt.Defer, false).Line] {
+		return true
+	}
+
+	// Unlocking via t.Cleanup would hold the lock for the rest of the test
+	if opts.AllowUnlock && isMutexUnlock(pass, deferStmt.Call) {
+		return true
+	}
+
+	// Panic recovery only works from a deferred call; t.Cleanup has no equivalent
+	if opts.AllowRecover && isRecoverOnly(pass, deferStmt.Call) {
+		return true
+	}
+
+	// Timer controls bracket the measured region of a benchmark; t.Cleanup has no equivalent
+	if opts.AllowTimerControls && isTimerControl(pass, deferStmt.Call) {
+		return true
+	}
+
+	// Ending a span or region only records that it ended, wherever the test stops
+	if opts.AllowSpanEnd && isSpanEnd(deferStmt.Call, opts.SpanEndMethod) {
+		return true
+	}
+
+	if len(opts.AllowedDeferCalls) > 0 && isAllowedCall(pass, opts.AllowedDeferCalls, deferStmt.Call) {
+		return true
+	}
+
+	if len(opts.AllowPackages) > 0 && isAllowedPackage(pass, opts.AllowPackages, deferStmt.Call) {
+		return true
+	}
+
+	// An empty function does nothing, so there is no cleanup to move to t.Cleanup
+	if opts.IgnoreEmptyDefers && isEmptyFuncLit(deferStmt.Call) {
+		return true
+	}
+
+	return false
+}
+
+// isTimerControl checks if the call is StopTimer, StartTimer, or ResetTimer on a *testing.B
+func isTimerControl(pass *analysis.Pass, call *ast.CallExpr) bool {
+	fn := calledFunc(pass, call)
+	if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != "testing" {
+		return false
+	}
+	switch fn.Name() {
+	case "StopTimer", "StartTimer", "ResetTimer":
+		return true
+	}
+	return false
+}
+
+// isSpanEnd checks if the call is a method named method, such as span.End()
+// or trace.StartRegion(ctx, "x").End()
+func isSpanEnd(call *ast.CallExpr, method string) bool {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == method
+}
+
+// isEmptyFuncLit checks if the call is an empty function literal called without arguments
+func isEmptyFuncLit(call *ast.CallExpr) bool {
+	lit, ok := ast.Unparen(call.Fun).(*ast.FuncLit)
+	return ok && len(call.Args) == 0 && len(lit.Body.List) == 0
+}
+
+// isMutexUnlock checks if the call is Unlock or RUnlock on a sync.Mutex or sync.RWMutex
+func isMutexUnlock(pass *analysis.Pass, call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+
+	fn, ok := pass.TypesInfo.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "sync" {
+		return false
+	}
+
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return false
+	}
+
+	typ := recv.Type()
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return false
+	}
+
+	switch named.Obj().Name() + "." + fn.Name() {
+	case "Mutex.Unlock", "RWMutex.Unlock", "RWMutex.RUnlock":
+		return true
+	}
+	return false
+}
+
+// isAllowedCall checks if the deferred call, or the single call in a deferred function
+// literal such as func() { os.Remove(name) }(), is one of the allowed calls, as listed in -allow-calls
+func isAllowedCall(pass *analysis.Pass, allowed []string, call *ast.CallExpr) bool {
+	if lit, ok := call.Fun.(*ast.FuncLit); ok && len(call.Args) == 0 {
+		if len(lit.Body.List) != 1 {
+			return false
+		}
+		stmt, ok := lit.Body.List[0].(*ast.ExprStmt)
+		if !ok {
+			return false
+		}
+		if call, ok = ast.Unparen(stmt.X).(*ast.CallExpr); !ok {
+			return false
+		}
+	}
+
+	// Match the selector as written, such as os.Remove or f.Close
+	if slices.Contains(allowed, types.ExprString(call.Fun)) {
+		return true
+	}
+
+	// Match the qualified name, such as os.Remove or (*os.File).Close
+	fn := calledFunc(pass, call)
+	return fn != nil && slices.Contains(allowed, fn.FullName())
+}
+
+// isAllowedPackage checks if the deferred function or method is declared in one of the
+// packages, as listed in -allow-packages, which provide cleanup helpers designed to be deferred
+func isAllowedPackage(pass *analysis.Pass, packages []string, call *ast.CallExpr) bool {
+	fn := calledFunc(pass, call)
+	return fn != nil && fn.Pkg() != nil && slices.Contains(packages, trimVendor(fn.Pkg().Path()))
+}
+
+// isRecoverOnly checks if the call is a function literal that only recovers from a panic:
+// every top-level statement of its body calls recover(), stores its result, or is an if
+// statement handling the recovered value, so there is nothing resembling cleanup in it
+func isRecoverOnly(pass *analysis.Pass, call *ast.CallExpr) bool {
+	lit, ok := call.Fun.(*ast.FuncLit)
+	if !ok || len(call.Args) > 0 || lit.Body == nil {
+		return false
+	}
+
+	recovered := make(map[types.Object]bool) // variables holding the result of recover()
+	found := false
+	for _, stmt := range lit.Body.List {
+		switch stmt := stmt.(type) {
+		case *ast.ExprStmt:
+			if !isRecoverCall(pass, stmt.X) {
+				return false
+			}
+		case *ast.AssignStmt:
+			if !isRecoverAssign(pass, stmt, recovered) {
+				return false
+			}
+		case *ast.IfStmt:
+			// if r := recover(); r != nil {...} or if recover() != nil {...}
+			if init, ok := stmt.Init.(*ast.AssignStmt); ok {
+				if !isRecoverAssign(pass, init, recovered) {
+					return false
+				}
+			} else if stmt.Init != nil {
+				return false
+			}
+			if !usesRecovered(pass, stmt.Cond, recovered) {
+				return false
+			}
+		default:
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// isRecoverAssign checks if the statement assigns the result of recover(),
+// recording the assigned variables
+func isRecoverAssign(pass *analysis.Pass, assign *ast.AssignStmt, recovered map[types.Object]bool) bool {
+	if len(assign.Lhs) != 1 || len(assign.Rhs) != 1 || !isRecoverCall(pass, assign.Rhs[0]) {
+		return false
+	}
+	if ident, ok := assign.Lhs[0].(*ast.Ident); ok {
+		if obj := pass.TypesInfo.ObjectOf(ident); obj != nil {
+			recovered[obj] = true
+		}
+	}
+	return true
+}
+
+// usesRecovered checks if the expression calls recover() or refers to a recovered value
+func usesRecovered(pass *analysis.Pass, expr ast.Expr, recovered map[types.Object]bool) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			if isRecoverCall(pass, n) {
+				found = true
+			}
+		case *ast.Ident:
+			if recovered[pass.TypesInfo.ObjectOf(n)] {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// isRecoverCall checks if the expression is a call to the recover builtin
+func isRecoverCall(pass *analysis.Pass, expr ast.Expr) bool {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	ident, ok := ast.Unparen(call.Fun).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	_, ok = pass.TypesInfo.Uses[ident].(*types.Builtin)
+	return ok && ident.Name == "recover"
+}
@@ -0,0 +1,16 @@
+// Command checks runs nodefertest together with other analyzers from a single binary.
+// Each analyzer's flags are prefixed with its name, as in -nodefertest.prefixes.
+package main
+
+import (
+	"github.com/s4s7/nodefertest"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/multichecker"
+)
+
+// analyzers are the analyzers bundled in the command; append in-house analyzers here
+var analyzers = []*analysis.Analyzer{
+	nodefertest.Analyzer,
+}
+
+func main() { multichecker.Main(analyzers...) }
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/s4s7/nodefertest"
+)
+
+// TestAnalyzers checks that nodefertest is part of the bundle.
+func TestAnalyzers(t *testing.T) {
+	if !slices.Contains(analyzers, nodefertest.Analyzer) {
+		t.Error("nodefertest.Analyzer is not in analyzers")
+	}
+}
+
+// TestMain_flags builds the command and checks that the flags of nodefertest are
+// namespaced with its name, as multichecker does.
+func TestMain_flags(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "checks")
+	if out, err := exec.Command("go", "build", "-o", bin, ".").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+
+	out, err := exec.Command(bin, "-flags").Output()
+	if err != nil {
+		t.Fatalf("checks -flags: %v", err)
+	}
+
+	var flags []struct{ Name string }
+	if err := json.Unmarshal(out, &flags); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	found := slices.ContainsFunc(flags, func(f struct{ Name string }) bool {
+		return f.Name == "nodefertest.prefixes"
+	})
+	if !found {
+		t.Errorf("flag nodefertest.prefixes not found in:\n%s", out)
+	}
+}
@@ -0,0 +1,10 @@
+// Command nodefertest-nogo runs nodefertest with the unitchecker protocol of go vet
+// -vettool, which build systems such as Bazel's nogo also use.
+package main
+
+import (
+	"github.com/s4s7/nodefertest"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() { unitchecker.Main(nodefertest.Analyzer) }
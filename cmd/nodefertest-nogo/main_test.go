@@ -0,0 +1,32 @@
+package main_test
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain_vet builds the command and runs it through go vet against the smoke
+// module of cmd/nodefertest.
+func TestMain_vet(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "nodefertest-nogo")
+	if out, err := exec.Command("go", "build", "-o", bin, ".").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+
+	cmd := exec.Command("go", "vet", "-vettool="+bin, "./...")
+	cmd.Dir = filepath.Join("..", "nodefertest", "testdata", "smoke")
+	out, err := cmd.CombinedOutput()
+
+	// go vet exits with status 1 when diagnostics are reported
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != 1 {
+		t.Fatalf("got error %v, want exit status 1\n%s", err, out)
+	}
+
+	want := `smoke_test.go:6:2: use t.Cleanup() instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function "TestSmoke"`
+	if !strings.Contains(string(out), want) {
+		t.Errorf("output does not contain %q:\n%s", want, out)
+	}
+}
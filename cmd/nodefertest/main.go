@@ -2,7 +2,7 @@ package main
 
 import (
 	"github.com/s4s7/nodefertest"
-	"golang.org/x/tools/go/analysis/unitchecker"
+	"golang.org/x/tools/go/analysis/singlechecker"
 )
 
-func main() { unitchecker.Main(nodefertest.Analyzer) }
+func main() { singlechecker.Main(nodefertest.Analyzer) }
@@ -0,0 +1,53 @@
+package main_test
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain_smoke builds the command and runs it against a tiny module
+// through go vet, which singlechecker supports alongside standalone use.
+func TestMain_smoke(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "nodefertest")
+	if out, err := exec.Command("go", "build", "-o", bin, ".").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+
+	cmd := exec.Command("go", "vet", "-vettool="+bin, "./...")
+	cmd.Dir = filepath.Join("testdata", "smoke")
+	out, err := cmd.CombinedOutput()
+
+	// go vet exits with status 1 when diagnostics are reported
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != 1 {
+		t.Fatalf("got error %v, want exit status 1\n%s", err, out)
+	}
+
+	want := `smoke_test.go:6:2: use t.Cleanup() instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function "TestSmoke"`
+	if !strings.Contains(string(out), want) {
+		t.Errorf("output does not contain %q:\n%s", want, out)
+	}
+}
+
+// TestMain_quiet runs the command standalone on a module without diagnostics, which
+// writes nothing to standard error unless the flags of the analyzer conflict with those
+// of the driver.
+func TestMain_quiet(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "nodefertest")
+	if out, err := exec.Command("go", "build", "-o", bin, ".").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+
+	var stderr strings.Builder
+	cmd := exec.Command(bin, "./...")
+	cmd.Dir = filepath.Join("testdata", "clean")
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("got error %v, want none\n%s", err, stderr.String())
+	}
+	if stderr.Len() > 0 {
+		t.Errorf("got standard error output, want none:\n%s", stderr.String())
+	}
+}
@@ -0,0 +1,7 @@
+package clean
+
+import "testing"
+
+func TestClean(t *testing.T) {
+	t.Cleanup(func() {})
+}
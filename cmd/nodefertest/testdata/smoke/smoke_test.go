@@ -0,0 +1,9 @@
+package smoke
+
+import "testing"
+
+func TestSmoke(t *testing.T) {
+	defer cleanup()
+}
+
+func cleanup() {}
@@ -0,0 +1,210 @@
+package nodefertest
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// configFlag is the path of a configuration file, loaded into the other flags when set
+type configFlag string
+
+func (c *configFlag) String() string {
+	return string(*c)
+}
+
+func (c *configFlag) Set(path string) error {
+	if path != "" {
+		if err := loadConfig(path); err != nil {
+			return err
+		}
+	}
+	*c = configFlag(path)
+	return nil
+}
+
+// setFlags records the flags of Analyzer that have been set, on the command line or
+// otherwise, other than from a configuration file
+var setFlags = make(map[string]bool)
+
+// trackedValue is the value of a flag of Analyzer that records in setFlags when it is set
+type trackedValue struct {
+	flag.Value
+	name string
+}
+
+func (v *trackedValue) Set(s string) error {
+	if err := v.Value.Set(s); err != nil {
+		return err
+	}
+	setFlags[v.name] = true
+	return nil
+}
+
+// IsBoolFlag lets -name stand for -name=true for the boolean flags, as without tracking
+func (v *trackedValue) IsBoolFlag() bool {
+	b, ok := v.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// trackFlags makes the flags of Analyzer record when they are set, so that the settings
+// of a configuration file leave them as they are
+func trackFlags() {
+	Analyzer.Flags.VisitAll(func(f *flag.Flag) {
+		if f.Name != "config" {
+			f.Value = &trackedValue{Value: f.Value, name: f.Name}
+		}
+	})
+}
+
+// loadConfig sets the flags listed in the configuration file at path. Flags already
+// set, such as those given before -config on the command line, take precedence and
+// are left as is, even when set to their defaults; flags given after -config override
+// the file.
+func loadConfig(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	settings, err := parseConfig(data)
+	if err != nil {
+		return fmt.Errorf("%s:%w", path, err)
+	}
+
+	for _, s := range settings {
+		f := Analyzer.Flags.Lookup(s.name)
+		if f == nil || s.name == "config" {
+			return fmt.Errorf("%s:%d: unknown setting %q", path, s.line, s.name)
+		}
+		if setFlags[s.name] {
+			continue
+		}
+		// Set the value itself, so that the setting is not taken for a flag
+		if err := f.Value.(*trackedValue).Value.Set(s.value); err != nil {
+			return fmt.Errorf("%s:%d: invalid setting %q: %w", path, s.line, s.name, err)
+		}
+	}
+	return nil
+}
+
+// setting is a flag value from a configuration file
+type setting struct {
+	name, value string
+	// line is the line of the name in the file, for errors
+	line int
+}
+
+// parseConfig parses the YAML subset of configuration files: a mapping of flag names
+// to scalars or to lists of scalars, in block or flow style, which are joined with
+// commas like the values of list flags. Comments start with #.
+//
+//	allow-calls:
+//	  - os.RemoveAll
+//	  - (*os.File).Close
+//	exclude-functions: [TestLegacy, TestFixture]
+//	check-helpers: true
+func parseConfig(data []byte) ([]setting, error) {
+	var settings []setting
+	// list is the setting whose block list items are being read, if any
+	var list *setting
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(stripComment(scanner.Text()), " \t")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		if item, ok := strings.CutPrefix(strings.TrimSpace(text), "- "); ok {
+			if list == nil {
+				return nil, fmt.Errorf("%d: list item without a setting", line)
+			}
+			value, err := unquote(strings.TrimSpace(item))
+			if err != nil {
+				return nil, fmt.Errorf("%d: %w", line, err)
+			}
+			if list.value != "" {
+				list.value += ","
+			}
+			list.value += value
+			continue
+		}
+		list = nil
+
+		name, value, ok := strings.Cut(text, ":")
+		if !ok || name != strings.TrimSpace(name) || name == "" {
+			return nil, fmt.Errorf("%d: want name: value, got %q", line, text)
+		}
+		value = strings.TrimSpace(value)
+		settings = append(settings, setting{name: name, line: line})
+		s := &settings[len(settings)-1]
+
+		switch {
+		case value == "":
+			// The value is the block list below, if any; otherwise it is empty
+			list = s
+		case strings.HasPrefix(value, "["):
+			items, ok := strings.CutSuffix(value[1:], "]")
+			if !ok {
+				return nil, fmt.Errorf("%d: unterminated list %s", line, value)
+			}
+			var values []string
+			for item := range strings.SplitSeq(items, ",") {
+				if item = strings.TrimSpace(item); item == "" {
+					continue
+				}
+				v, err := unquote(item)
+				if err != nil {
+					return nil, fmt.Errorf("%d: %w", line, err)
+				}
+				values = append(values, v)
+			}
+			s.value = strings.Join(values, ",")
+		default:
+			v, err := unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %w", line, err)
+			}
+			s.value = v
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// stripComment removes a # comment outside of quotes from the line
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquote returns the scalar s without its double or single quotes, if any
+func unquote(s string) (string, error) {
+	switch {
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		return strconv.Unquote(s)
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'':
+		// Single quotes are escaped by doubling them
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'"):
+		return "", fmt.Errorf("unterminated string %s", s)
+	}
+	return s, nil
+}
@@ -0,0 +1,186 @@
+package nodefertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Kinds of Diagnostic, naming the shape of the reported defer or call
+const (
+	KindDefer         = "defer"
+	KindLoop          = "loop"
+	KindBenchmarkLoop = "benchmark-loop"
+	KindGoroutine     = "goroutine"
+	KindSharedGo      = "shared-goroutine"
+	KindParallel      = "parallel"
+	KindSelfParallel  = "self-parallel"
+	KindSetup         = "setup"
+	KindNamedResult   = "named-result"
+	KindExample       = "example"
+	KindCleanupFunc   = "cleanup-func"
+	KindCleanupFatal  = "cleanup-fatal"
+	KindGinkgo        = "ginkgo"
+	KindHelperCall    = "helper-call"
+	KindMixed         = "mixed"
+	KindMaxDepth      = "max-depth"
+	KindUnlock        = "unlock"
+)
+
+// categories are the categories reported to the analysis framework for each kind,
+// after the severity, which editors use to filter diagnostics; they are stable across
+// releases
+var categories = map[string]string{
+	KindDefer:         "defer-generic",
+	KindLoop:          "defer-in-loop",
+	KindBenchmarkLoop: "defer-in-benchmark-loop",
+	KindGoroutine:     "defer-in-goroutine",
+	KindSharedGo:      "defer-shared-with-goroutine",
+	KindParallel:      "defer-before-parallel",
+	KindSelfParallel:  "defer-before-t-parallel",
+	KindSetup:         "defer-setup-teardown",
+	KindNamedResult:   "defer-named-result",
+	KindExample:       "defer-in-example",
+	KindCleanupFunc:   "defer-in-cleanup",
+	KindCleanupFatal:  "fatal-in-cleanup",
+	KindGinkgo:        "defer-in-ginkgo",
+	KindHelperCall:    "deferring-helper",
+	KindMixed:         "defer-mixed-cleanup",
+	KindMaxDepth:      "max-depth",
+	KindUnlock:        "defer-unlock",
+}
+
+// Diagnostic is a defer reported by Analyzer. The result of Analyzer for each
+// package is the []Diagnostic reported in it.
+type Diagnostic struct {
+	// File, Line, and Column are the position of the defer keyword
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	// TestName is the name of the enclosing test function declaration
+	TestName string `json:"test_name"`
+	// Kind is one of the Kind constants
+	Kind     string `json:"kind"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+	// URL is the documentation of the diagnostic's category
+	URL string `json:"url"`
+	// Fix is the t.Cleanup call suggested in place of the defer, with function
+	// literal bodies elided, or empty if no fix is suggested
+	Fix string `json:"fix,omitempty"`
+
+	// Pos and End are the range of the reported defer or call; End is NoPos
+	// for diagnostics reported at a single position
+	Pos token.Pos `json:"-"`
+	End token.Pos `json:"-"`
+	// SuggestedFix is the edit replacing the defer with a t.Cleanup call, or
+	// nil if no fix is suggested
+	SuggestedFix *SuggestedFix `json:"-"`
+	// Related are other positions explaining the diagnostic, such as the t.Fatal
+	// call that skips the deferred call
+	Related []RelatedInformation `json:"-"`
+}
+
+// RelatedInformation is a position related to a Diagnostic
+type RelatedInformation struct {
+	Pos     token.Pos
+	End     token.Pos
+	Message string
+}
+
+// SuggestedFix is a change that resolves a Diagnostic
+type SuggestedFix struct {
+	Message string
+	Edits   []TextEdit
+}
+
+// TextEdit replaces the text in [Pos, End) with NewText
+type TextEdit struct {
+	Pos     token.Pos
+	End     token.Pos
+	NewText string
+}
+
+// analysisDiagnostic translates d to the diagnostic reported to the analysis
+// framework, categorized by its severity and kind, as in error/defer-in-loop, since
+// the category is all that drivers such as golangci-lint and gopls know of either
+func (d Diagnostic) analysisDiagnostic(opts *Options) analysis.Diagnostic {
+	diag := analysis.Diagnostic{
+		Pos:      d.Pos,
+		End:      d.End,
+		Category: d.Severity + "/" + categories[d.Kind],
+		URL:      d.URL,
+		Message:  d.Message,
+	}
+	// Under -print-fixes the fixes are written out for review rather than applied
+	if d.SuggestedFix != nil && !opts.PrintFixes {
+		edits := make([]analysis.TextEdit, len(d.SuggestedFix.Edits))
+		for i, e := range d.SuggestedFix.Edits {
+			edits[i] = analysis.TextEdit{Pos: e.Pos, End: e.End, NewText: []byte(e.NewText)}
+		}
+		diag.SuggestedFixes = []analysis.SuggestedFix{{
+			Message:   d.SuggestedFix.Message,
+			TextEdits: edits,
+		}}
+	}
+	if d.Fix != "" {
+		// The replacement also as related information, for drivers that show it
+		// but do not apply fixes
+		diag.Related = []analysis.RelatedInformation{{
+			Pos:     d.Pos,
+			End:     d.End,
+			Message: "replace with " + d.Fix,
+		}}
+	}
+	for _, r := range d.Related {
+		diag.Related = append(diag.Related, analysis.RelatedInformation{Pos: r.Pos, End: r.End, Message: r.Message})
+	}
+	return diag
+}
+
+// FormatDiagnostics writes diags to w as a JSON array of objects with the keys
+// "file", "line", "column", "test_name", "kind", "message", "severity", "url",
+// and, when a fix is suggested, "fix". This schema is stable across releases; keys
+// are only ever added.
+func FormatDiagnostics(w io.Writer, diags []Diagnostic) error {
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(diags)
+}
+
+// summaryCategory is the category of the diagnostic reported by reportSummary
+const summaryCategory = "summary"
+
+// reportSummary reports the number of diagnostics in the package, grouped by test
+// function in name order, on the package clause of its first file
+func reportSummary(pass *analysis.Pass, diags []Diagnostic) {
+	counts := make(map[string]int)
+	for _, d := range diags {
+		counts[d.TestName]++
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	groups := make([]string, len(names))
+	for i, name := range names {
+		groups[i] = fmt.Sprintf("%s: %d", name, counts[name])
+	}
+
+	pass.Report(analysis.Diagnostic{
+		Pos:      pass.Files[0].Package,
+		Category: summaryCategory,
+		Message:  fmt.Sprintf("nodefertest summary: %d diagnostics in %d test functions (%s)", len(diags), len(names), strings.Join(groups, ", ")),
+	})
+}
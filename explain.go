@@ -0,0 +1,29 @@
+package nodefertest
+
+import (
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// explainOutput is where -explain writes why functions are checked; explainOutputMu keeps the
+// lines of packages analyzed in parallel together
+var (
+	explainOutput   io.Writer = os.Stderr
+	explainOutputMu sync.Mutex
+)
+
+// writeExplanations writes the explanations of a package to w, one per line:
+//
+//	a_test.go:5:6: TestOpen: checked: a test function
+//	a_test.go:12:6: open: skipped: the name does not start with one of the -prefixes ...
+func writeExplanations(w io.Writer, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	explainOutputMu.Lock()
+	defer explainOutputMu.Unlock()
+	// Like the diagnostics, the output is best effort; a failed write does not fail the pass
+	_, _ = io.WriteString(w, strings.Join(lines, "\n")+"\n")
+}
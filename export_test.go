@@ -0,0 +1,36 @@
+package nodefertest
+
+import (
+	"go/ast"
+	"go/types"
+	"io"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// HasCleanupCall exposes hasCleanupCall to the tests of package nodefertest_test
+func HasCleanupCall(info *types.Info, body *ast.BlockStmt) bool {
+	return hasCleanupCall(&analysis.Pass{TypesInfo: info}, body)
+}
+
+// SetFixOutput redirects the output of -print-fixes to w until the returned function is called
+func SetFixOutput(w io.Writer) (restore func()) {
+	old := fixOutput
+	fixOutput = w
+	return func() { fixOutput = old }
+}
+
+// SetExplainOutput redirects the output of -explain to w until the returned function is called
+func SetExplainOutput(w io.Writer) (restore func()) {
+	old := explainOutput
+	explainOutput = w
+	return func() { explainOutput = old }
+}
+
+// ForgetSetFlags forgets which flags have been set, as before the command line is parsed,
+// until the returned function is called
+func ForgetSetFlags() (restore func()) {
+	old := setFlags
+	setFlags = make(map[string]bool)
+	return func() { setFlags = old }
+}
@@ -0,0 +1,126 @@
+package nodefertest
+
+import (
+	"fmt"
+	"go/ast"
+	"slices"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// deferringHelper is a fact marking a helper that takes a *testing.T, defers calls,
+// and may stop the test early, skipping them just like a defer in the test would
+type deferringHelper struct {
+	// Defers is the number of defer statements reported in the helper
+	Defers int
+}
+
+func (*deferringHelper) AFact() {}
+
+func (f *deferringHelper) String() string {
+	return fmt.Sprintf("deferringHelper(%d)", f.Defers)
+}
+
+// stoppingHelper is a fact marking a function that takes a testing parameter and may stop
+// the test, such as a helper calling t.Fatal, so that calls to it may stop the test too
+type stoppingHelper struct{}
+
+func (*stoppingHelper) AFact() {}
+
+func (*stoppingHelper) String() string {
+	return "stoppingHelper"
+}
+
+// exportStopFacts exports a stoppingHelper fact for each function of the package that
+// is not a test function but takes a testing parameter and may stop the test. Helpers
+// may call each other in any order of declaration, so the functions are scanned again
+// until no more are marked.
+func exportStopFacts(pass *analysis.Pass, opts *Options) {
+	var funcs []*ast.FuncDecl
+	for _, f := range pass.Files {
+		for _, decl := range f.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if ok && funcDecl.Body != nil && hasTestingTParam(pass, funcDecl) && !isTestFunction(opts, funcDecl) {
+				funcs = append(funcs, funcDecl)
+			}
+		}
+	}
+
+	for marked := true; marked; {
+		marked = false
+		funcs = slices.DeleteFunc(funcs, func(funcDecl *ast.FuncDecl) bool {
+			obj := pass.TypesInfo.Defs[funcDecl.Name]
+			if obj == nil || !callsFatal(pass, funcDecl.Body) {
+				return false
+			}
+			pass.ExportObjectFact(obj, &stoppingHelper{})
+			marked = true
+			return true
+		})
+	}
+}
+
+// exportHelperFacts exports a deferringHelper fact for each function of the files
+// that is not a test function but takes a *testing.T, defers calls that are not
+// allowed, and may stop the test early
+func exportHelperFacts(pass *analysis.Pass, opts *Options, files []*ast.File, ignoredIn func(*ast.File) map[int]bool) {
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Body == nil || !hasTestingTParam(pass, funcDecl) || isTestFunction(opts, funcDecl) {
+				continue
+			}
+
+			defers := countDefers(pass, opts, ignoredIn(f), funcDecl.Body)
+			if defers == 0 || !callsFatal(pass, funcDecl.Body) {
+				continue
+			}
+
+			if obj := pass.TypesInfo.Defs[funcDecl.Name]; obj != nil {
+				pass.ExportObjectFact(obj, &deferringHelper{Defers: defers})
+			}
+		}
+	}
+}
+
+// countDefers counts the defer statements of the function body that are not allowed,
+// excluding those in function literals
+func countDefers(pass *analysis.Pass, opts *Options, ignored map[int]bool, body *ast.BlockStmt) int {
+	n := 0
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.DeferStmt:
+			if !isAllowedDefer(pass, opts, ignored, node) {
+				n++
+			}
+		}
+		return true
+	})
+	return n
+}
+
+// checkHelperCall checks a call inside the test function ctx to a helper marked
+// with a deferringHelper fact. It returns the reported diagnostic, if any.
+func checkHelperCall(pass *analysis.Pass, opts *Options, ctx *testContext, ignored map[int]bool, call *ast.CallExpr) (Diagnostic, bool) {
+	fn := calledFunc(pass, call)
+	if fn == nil {
+		return Diagnostic{}, false
+	}
+
+	var fact deferringHelper
+	if !pass.ImportObjectFact(fn, &fact) {
+		return Diagnostic{}, false
+	}
+
+	if ignored[pass.Fset.PositionFor(call.Pos(), false).Line] {
+		return Diagnostic{}, false
+	}
+
+	return diagnose(pass, opts, ctx, Diagnostic{
+		Kind:    KindHelperCall,
+		Pos:     call.Pos(),
+		Message: fmt.Sprintf("helper %q defers calls that are skipped if it stops the test early; use t.Cleanup() in the helper in test function %q", fn.Name(), ctx.name),
+	}), true
+}
@@ -0,0 +1,137 @@
+package nodefertest
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// callsFatal checks if the test function body contains a call that may stop the
+// test via runtime.Goexit, so that deferred calls would be skipped. Function
+// literals with their own *testing.T parameter are separate test functions and
+// are not scanned, nor are the functions run in other goroutines or after the
+// deferred calls.
+func callsFatal(pass *analysis.Pass, body *ast.BlockStmt) bool {
+	return len(fatalCalls(pass, body)) > 0
+}
+
+// fatalCalls returns the calls in the test function body that may stop the test, such
+// as t.Fatal, in source order. Function literals with their own *testing.T parameter
+// are separate test functions and are not scanned, nor are the functions run in other
+// goroutines, whose t.Fatal only stops that goroutine, nor the deferred calls and the
+// functions passed to t.Cleanup, which run once the deferred calls are already running
+// or done.
+func fatalCalls(pass *analysis.Pass, body *ast.BlockStmt) []*ast.CallExpr {
+	var found []*ast.CallExpr
+	var inspect func(n ast.Node) bool
+	// inspectArgs inspects the arguments evaluated by the test itself, leaving out the
+	// function literals that run later or elsewhere
+	inspectArgs := func(args []ast.Expr) {
+		for _, arg := range args {
+			if _, ok := ast.Unparen(arg).(*ast.FuncLit); !ok {
+				ast.Inspect(arg, inspect)
+			}
+		}
+	}
+	inspect = func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncLit:
+			return !hasFuncLitTestingTParam(pass, node)
+		case *ast.GoStmt:
+			// Only the arguments are evaluated by the test itself
+			for _, arg := range node.Call.Args {
+				ast.Inspect(arg, inspect)
+			}
+			return false
+		case *ast.DeferStmt:
+			// The function and the arguments are evaluated now, but the call runs along
+			// with the other deferred calls, which runtime.Goexit still runs
+			inspectArgs(append([]ast.Expr{node.Call.Fun}, node.Call.Args...))
+			return false
+		case *ast.CallExpr:
+			if isFatalCall(pass, node) {
+				found = append(found, node)
+			}
+			// The function runs in a goroutine of the group, or after the test
+			if isGroupGo(pass, node) || isTestingMethod(pass, node, "Cleanup") {
+				ast.Inspect(node.Fun, inspect)
+				inspectArgs(node.Args)
+				return false
+			}
+		}
+		return true
+	}
+	ast.Inspect(body, inspect)
+	return found
+}
+
+// isGroupGo checks if the call is Go or TryGo of an errgroup.Group, which runs its
+// function in a new goroutine
+func isGroupGo(pass *analysis.Pass, call *ast.CallExpr) bool {
+	fn := calledFunc(pass, call)
+	if fn == nil || fn.Pkg() == nil || trimVendor(fn.Pkg().Path()) != "golang.org/x/sync/errgroup" {
+		return false
+	}
+	recv := fn.Signature().Recv()
+	return recv != nil && (fn.Name() == "Go" || fn.Name() == "TryGo")
+}
+
+// isFatalCall checks if the call is t.Fatal/t.FailNow/t.Skip and friends, a
+// testify require assertion, runtime.Goexit, or a helper marked with a stoppingHelper
+// fact, which takes the *testing.T and may stop the test itself
+func isFatalCall(pass *analysis.Pass, call *ast.CallExpr) bool {
+	fn := calledFunc(pass, call)
+	if fn != nil && fn.Pkg() != nil {
+		switch trimVendor(fn.Pkg().Path()) {
+		case "testing":
+			switch fn.Name() {
+			case "Fatal", "Fatalf", "FailNow", "Skip", "Skipf", "SkipNow":
+				return true
+			}
+		case "runtime":
+			return fn.Name() == "Goexit"
+		case "github.com/stretchr/testify/require":
+			return true
+		case "github.com/stretchr/testify/suite":
+			// s.Require() returns the require assertions bound to the suite
+			return fn.Name() == "Require"
+		case "github.com/stretchr/testify/assert":
+			// assert never stops the test, even though it receives t
+			return false
+		}
+	}
+
+	// Other calls receiving the *testing.T, such as fmt.Println(t), only stop the test
+	// if they are known to
+	return fn != nil && pass.ImportObjectFact(fn, new(stoppingHelper))
+}
+
+// calledFunc returns the function or method called, or nil for dynamic calls
+func calledFunc(pass *analysis.Pass, call *ast.CallExpr) *types.Func {
+	fun := ast.Unparen(call.Fun)
+	// Explicitly instantiated generic functions, as in assertEqual[int](t, ...)
+	switch index := fun.(type) {
+	case *ast.IndexExpr:
+		fun = index.X
+	case *ast.IndexListExpr:
+		fun = index.X
+	}
+
+	var ident *ast.Ident
+	switch fun := fun.(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	default:
+		return nil
+	}
+
+	fn, ok := pass.TypesInfo.Uses[ident].(*types.Func)
+	if !ok {
+		return nil
+	}
+	// Instances of a generic function share the facts of its declaration
+	return fn.Origin()
+}
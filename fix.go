@@ -0,0 +1,480 @@
+package nodefertest
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"go/version"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// suggestCleanupFix builds a fix that rewrites the defer statement to tName.Cleanup, where
+// tName is the innermost *testing.T, *testing.B, or *testing.F parameter, so benchmarks
+// get b.Cleanup and fuzz functions f.Cleanup.
+// Calls of a func() without arguments are passed as is, as in t.Cleanup(cleanup) or
+// t.Cleanup(srv.Close); other calls, such as f.Close() returning an error, os.Remove(name),
+// or the method expression (*T).Close(x), are wrapped in a closure, as in
+// t.Cleanup(func() { f.Close() }), unless the closure would read values that change after
+// the defer statement. Selectors without type information get no fix. form is how the
+// defer statement can be rewritten, from cleanupFormOf.
+func suggestCleanupFix(form cleanupForm, tName string, deferStmt *ast.DeferStmt) *SuggestedFix {
+	if form == noCleanup {
+		return nil
+	}
+
+	call := deferStmt.Call
+	// Parentheses around the function, as in defer (cleanup)(), are dropped
+	fun := ast.Unparen(call.Fun)
+	edits := []TextEdit{
+		{
+			// "defer cleanup()" -> "t.Cleanup(cleanup()"
+			Pos:     deferStmt.Defer,
+			End:     fun.Pos(),
+			NewText: tName + ".Cleanup(",
+		},
+		{
+			// "t.Cleanup(cleanup()" -> "t.Cleanup(cleanup)"
+			Pos:     fun.End(),
+			End:     call.End(),
+			NewText: ")",
+		},
+	}
+	if form == wrappedCleanup {
+		edits = []TextEdit{
+			{
+				// "defer conn.Close()" -> "t.Cleanup(func() { conn.Close()"
+				Pos:     deferStmt.Defer,
+				End:     call.Pos(),
+				NewText: tName + ".Cleanup(func() { ",
+			},
+			{
+				// "t.Cleanup(func() { conn.Close()" -> "t.Cleanup(func() { conn.Close() })"
+				Pos:     call.End(),
+				End:     call.End(),
+				NewText: " })",
+			},
+		}
+	}
+
+	return &SuggestedFix{
+		Message: "Replace defer with " + tName + ".Cleanup",
+		Edits:   edits,
+	}
+}
+
+// cleanupReplacement returns a short form of the t.Cleanup call that would replace
+// the defer statement in the given form, with function literal bodies elided, or "" if there is none
+func cleanupReplacement(pass *analysis.Pass, form cleanupForm, tName string, deferStmt *ast.DeferStmt) string {
+	if form == noCleanup {
+		return ""
+	}
+
+	call := deferStmt.Call
+	if _, ok := call.Fun.(*ast.FuncLit); ok {
+		return tName + ".Cleanup(func() {...})"
+	}
+
+	var node ast.Node = ast.Unparen(call.Fun)
+	if form == wrappedCleanup {
+		node = call
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, pass.Fset, node); err != nil {
+		return ""
+	}
+
+	if form == wrappedCleanup {
+		return tName + ".Cleanup(func() { " + buf.String() + " })"
+	}
+	return tName + ".Cleanup(" + buf.String() + ")"
+}
+
+// cleanupForm is how a defer statement is rewritten to t.Cleanup
+type cleanupForm int
+
+const (
+	// noCleanup is for defer statements that cannot be rewritten
+	noCleanup cleanupForm = iota
+	// bareCleanup passes the deferred function as is, as in t.Cleanup(cleanup)
+	bareCleanup
+	// wrappedCleanup wraps the deferred call in a closure, as in t.Cleanup(func() { f.Close() })
+	wrappedCleanup
+)
+
+// cleanupFormOf returns how the defer statement of the function fn, inside the given loops,
+// can be rewritten to tName.Cleanup. assigned are the last assignments of the variables
+// of fn, from lastAssignments.
+func cleanupFormOf(pass *analysis.Pass, opts *Options, fn ast.Node, assigned map[*types.Var]token.Pos, loops []ast.Stmt, tName string, deferStmt *ast.DeferStmt) cleanupForm {
+	if tName == "" || !hasCleanup(pass, opts) {
+		return noCleanup
+	}
+
+	call := deferStmt.Call
+	// recover only stops a panic when it is called by the deferred function itself, and
+	// returns nil in a cleanup function
+	if isRecoverCall(pass, call) || recovers(pass, call) {
+		return noCleanup
+	}
+
+	typ := pass.TypesInfo.TypeOf(call.Fun)
+	if typ == nil {
+		return noCleanup
+	}
+
+	if sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr); ok {
+		switch selectorKindOf(pass, sel) {
+		case methodExpr:
+			// (*T).Close(x) takes the receiver as an argument, so it cannot be passed as is,
+			// and is wrapped below if the receiver does not change
+		case unresolvedSelector:
+			// Without type information the rewrite may not compile
+			return noCleanup
+		}
+	}
+
+	// The function value and a method value's receiver are evaluated right away,
+	// just like for defer, so x.Close can be passed as is
+	if len(call.Args) == 0 && isNiladicFunc(typ) {
+		return bareCleanup
+	}
+	// defer evaluates the function and its arguments right away, but the closure only
+	// when the cleanup runs, so they must not change in between
+	if !evaluatesAlike(pass, opts, fn, assigned, loops, deferStmt) {
+		return noCleanup
+	}
+	return wrappedCleanup
+}
+
+// recovers checks if the deferred call is a function literal calling recover() itself,
+// outside nested function literals, where it would not stop the panic either
+func recovers(pass *analysis.Pass, call *ast.CallExpr) bool {
+	lit, ok := ast.Unparen(call.Fun).(*ast.FuncLit)
+	if !ok {
+		return false
+	}
+
+	found := false
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false
+		}
+		if expr, ok := n.(ast.Expr); ok && isRecoverCall(pass, expr) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// evaluatesAlike checks if the function and the arguments of the deferred call have the
+// same values when the cleanup runs as when the defer statement of the function fn runs:
+// constants, functions, and the local variables of fn that are not assigned after the
+// defer statement, or anywhere in the loops around it
+func evaluatesAlike(pass *analysis.Pass, opts *Options, fn ast.Node, assigned map[*types.Var]token.Pos, loops []ast.Stmt, deferStmt *ast.DeferStmt) bool {
+	call := deferStmt.Call
+	exprs := slices.Clone(call.Args)
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.FuncLit:
+		// The literal captures variables by reference either way
+	case *ast.SelectorExpr:
+		if selectorKindOf(pass, fun) == methodExpr {
+			break
+		}
+		exprs = append(exprs, fun)
+	default:
+		exprs = append(exprs, fun)
+	}
+
+	changes := assignedAfter(pass, opts, assigned, loops, deferStmt)
+	var stable func(expr ast.Expr) bool
+	stable = func(expr ast.Expr) bool {
+		switch expr := ast.Unparen(expr).(type) {
+		case *ast.BasicLit, *ast.FuncLit:
+			return true
+		case *ast.Ident:
+			v, ok := pass.TypesInfo.Uses[expr].(*types.Var)
+			if !ok {
+				// Constants, functions, types, and nil
+				return pass.TypesInfo.Uses[expr] != nil
+			}
+			return v.Pos() >= fn.Pos() && v.Pos() < fn.End() && !changes[v]
+		case *ast.SelectorExpr:
+			if selectorKindOf(pass, expr) == qualifiedSelector {
+				_, isVar := pass.TypesInfo.Uses[expr.Sel].(*types.Var)
+				return !isVar
+			}
+			return stable(expr.X)
+		case *ast.UnaryExpr:
+			if _, ok := ast.Unparen(expr.X).(*ast.Ident); ok && expr.Op == token.AND {
+				return true // The address of a variable does not change
+			}
+			return expr.Op != token.ARROW && stable(expr.X)
+		case *ast.BinaryExpr:
+			return stable(expr.X) && stable(expr.Y)
+		case *ast.CompositeLit:
+			for _, elt := range expr.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					elt = kv.Value
+				}
+				if !stable(elt) {
+					return false
+				}
+			}
+			return true
+		case *ast.CallExpr:
+			// Only conversions evaluate to the same value twice
+			return isConversion(pass, expr) && len(expr.Args) == 1 && stable(expr.Args[0])
+		}
+		return false
+	}
+	for _, expr := range exprs {
+		if !stable(expr) {
+			return false
+		}
+	}
+	return true
+}
+
+// assignedAfter returns the variables assigned after the defer statement, or anywhere in
+// the loops around it, given the last assignments of each from lastAssignments. The
+// variables of the loops are included too before Go 1.22, as they are shared by all
+// iterations.
+func assignedAfter(pass *analysis.Pass, opts *Options, assigned map[*types.Var]token.Pos, loops []ast.Stmt, deferStmt *ast.DeferStmt) map[*types.Var]bool {
+	changes := make(map[*types.Var]bool)
+	sharedLoopVars := !goVersionAtLeast(pass, opts, loopVarGoVersion)
+	from := deferStmt.Pos()
+	for _, loop := range loops {
+		from = min(from, loop.Pos())
+		var vars []ast.Expr
+		switch loop := loop.(type) {
+		case *ast.ForStmt:
+			if init, ok := loop.Init.(*ast.AssignStmt); ok && init.Tok == token.DEFINE {
+				vars = init.Lhs
+			}
+		case *ast.RangeStmt:
+			if loop.Tok == token.DEFINE {
+				vars = []ast.Expr{loop.Key, loop.Value}
+			}
+		}
+		for _, expr := range vars {
+			if ident, ok := expr.(*ast.Ident); ok && sharedLoopVars {
+				if v, ok := pass.TypesInfo.Defs[ident].(*types.Var); ok {
+					changes[v] = true
+				}
+			}
+		}
+	}
+
+	for v, pos := range assigned {
+		if pos >= from {
+			changes[v] = true
+		}
+	}
+	return changes
+}
+
+// lastAssignments returns the position of the last assignment to each variable in the
+// body, including in function literals, which may run at any time. Variables whose
+// address is taken may be assigned through it at any time, so they are given the end of
+// the body.
+func lastAssignments(pass *analysis.Pass, body *ast.BlockStmt) map[*types.Var]token.Pos {
+	assigned := make(map[*types.Var]token.Pos)
+	assign := func(expr ast.Expr, pos token.Pos) {
+		// The root of x.f, x[i], and *x, whose value changes with them
+		for {
+			switch e := ast.Unparen(expr).(type) {
+			case *ast.SelectorExpr:
+				expr = e.X
+				continue
+			case *ast.IndexExpr:
+				expr = e.X
+				continue
+			case *ast.StarExpr:
+				expr = e.X
+				continue
+			}
+			break
+		}
+		if ident, ok := ast.Unparen(expr).(*ast.Ident); ok {
+			if v, ok := pass.TypesInfo.Uses[ident].(*types.Var); ok {
+				assigned[v] = max(assigned[v], pos)
+			}
+		}
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.UnaryExpr:
+			if node.Op == token.AND {
+				assign(node.X, body.End())
+			}
+		case *ast.AssignStmt:
+			// Variables declared by := are in Defs, so only the reused ones are in Uses
+			for _, lhs := range node.Lhs {
+				assign(lhs, node.Pos())
+			}
+		case *ast.IncDecStmt:
+			assign(node.X, node.Pos())
+		case *ast.RangeStmt:
+			if node.Tok == token.ASSIGN {
+				if node.Key != nil {
+					assign(node.Key, node.Pos())
+				}
+				if node.Value != nil {
+					assign(node.Value, node.Pos())
+				}
+			}
+		}
+		return true
+	})
+	return assigned
+}
+
+// cleanupGoVersion is the first Go version with the Cleanup method of *testing.T and *testing.B
+const cleanupGoVersion = "go1.14"
+
+// loopVarGoVersion is the first Go version declaring loop variables per iteration
+const loopVarGoVersion = "go1.22"
+
+// hasCleanup checks if the Go version targeted by the package, from -min-go or else
+// from its go directive, has the Cleanup method to rewrite defers to
+func hasCleanup(pass *analysis.Pass, opts *Options) bool {
+	return goVersionAtLeast(pass, opts, cleanupGoVersion)
+}
+
+// goVersionAtLeast checks if the Go version targeted by the package, from -min-go or else
+// from its go directive, is at least the version want
+func goVersionAtLeast(pass *analysis.Pass, opts *Options, want string) bool {
+	v := opts.MinGo
+	if v == "" && pass.Pkg != nil {
+		v = pass.Pkg.GoVersion()
+	}
+	if v == "" {
+		return true // Unknown, so assume a recent toolchain
+	}
+	if !strings.HasPrefix(v, "go") {
+		v = "go" + v
+	}
+	return version.Compare(v, want) >= 0
+}
+
+// selectorKind is what a selector expression in a deferred call refers to
+type selectorKind int
+
+const (
+	// unresolvedSelector is a selector without type information
+	unresolvedSelector selectorKind = iota
+	// qualifiedSelector is a package-qualified function or variable, as in os.Clearenv
+	qualifiedSelector
+	// methodValue is a method bound to its receiver, as in x.Close
+	methodValue
+	// methodExpr is a method expression taking the receiver as an argument, as in (*T).Close
+	methodExpr
+	// fieldValue is a struct field of function type, as in s.stop
+	fieldValue
+)
+
+// selectorKindOf returns what the selector expression refers to
+func selectorKindOf(pass *analysis.Pass, sel *ast.SelectorExpr) selectorKind {
+	if selection, ok := pass.TypesInfo.Selections[sel]; ok {
+		switch selection.Kind() {
+		case types.MethodVal:
+			return methodValue
+		case types.MethodExpr:
+			return methodExpr
+		case types.FieldVal:
+			return fieldValue
+		}
+	}
+
+	if ident, ok := sel.X.(*ast.Ident); ok {
+		if _, ok := pass.TypesInfo.Uses[ident].(*types.PkgName); ok && pass.TypesInfo.Uses[sel.Sel] != nil {
+			return qualifiedSelector
+		}
+	}
+	return unresolvedSelector
+}
+
+// isNiladicFunc checks if the type is a func() with no parameters and no results,
+// which is the signature t.Cleanup accepts
+func isNiladicFunc(typ types.Type) bool {
+	if typ == nil {
+		return false
+	}
+
+	sig, ok := typ.Underlying().(*types.Signature)
+	if !ok {
+		return false
+	}
+
+	return sig.Params().Len() == 0 && sig.Results().Len() == 0
+}
+
+// fixOutput is where -print-fixes writes the fixes; fixOutputMu serializes the writes
+// of packages analyzed in parallel
+var (
+	fixOutput   io.Writer = os.Stdout
+	fixOutputMu sync.Mutex
+)
+
+// writeFixes writes the suggested fixes of diags to w for review, grouped by file, as
+// the source of each defer statement followed by its replacement:
+//
+//	a_test.go:
+//		6:2: defer cleanup()
+//		  => t.Cleanup(cleanup)
+func writeFixes(w io.Writer, pass *analysis.Pass, diags []Diagnostic) error {
+	readFile := pass.ReadFile
+	if readFile == nil {
+		readFile = os.ReadFile
+	}
+
+	var buf bytes.Buffer
+	file := ""
+	for _, d := range diags {
+		if d.SuggestedFix == nil {
+			continue
+		}
+		tf := pass.Fset.File(d.Pos)
+		src, err := readFile(tf.Name())
+		if err != nil {
+			return err
+		}
+		if tf.Name() != file {
+			file = tf.Name()
+			fmt.Fprintf(&buf, "%s:\n", file)
+		}
+
+		// Apply the edits, which are in order, to the source of the defer statement
+		start, end := tf.Offset(d.Pos), tf.Offset(d.End)
+		var fixed strings.Builder
+		offset := start
+		for _, e := range d.SuggestedFix.Edits {
+			fixed.Write(src[offset:tf.Offset(e.Pos)])
+			fixed.WriteString(e.NewText)
+			offset = tf.Offset(e.End)
+		}
+		fixed.Write(src[offset:end])
+
+		fmt.Fprintf(&buf, "\t%d:%d: %s\n\t  => %s\n", d.Line, d.Column, src[start:end], fixed.String())
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+
+	fixOutputMu.Lock()
+	defer fixOutputMu.Unlock()
+	_, err := w.Write(buf.Bytes())
+	return err
+}
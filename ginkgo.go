@@ -0,0 +1,49 @@
+package nodefertest
+
+import (
+	"go/ast"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// ginkgoNodes are the Ginkgo functions whose closures run as part of a spec, unlike
+// containers such as Describe, whose closures run while building the spec tree
+var ginkgoNodes = []string{
+	"It", "Specify", "FIt", "FSpecify", "PIt", "PSpecify",
+	"BeforeEach", "AfterEach", "JustBeforeEach", "JustAfterEach",
+	"BeforeAll", "AfterAll", "BeforeSuite", "AfterSuite",
+	"SynchronizedBeforeSuite", "SynchronizedAfterSuite",
+}
+
+// ginkgoNode checks if the innermost node of the stack is a function literal passed to
+// a Ginkgo node such as It or BeforeEach, and returns its name: the text of It and
+// Specify nodes, or the name of the node otherwise
+func ginkgoNode(pass *analysis.Pass, stack []ast.Node) (string, bool) {
+	if len(stack) < 2 {
+		return "", false
+	}
+	call, ok := stack[len(stack)-2].(*ast.CallExpr)
+	if !ok || !slices.Contains(call.Args, ast.Expr(stack[len(stack)-1].(*ast.FuncLit))) {
+		return "", false
+	}
+
+	fn := calledFunc(pass, call)
+	if fn == nil || fn.Pkg() == nil || !slices.Contains(ginkgoNodes, fn.Name()) {
+		return "", false
+	}
+	path := trimVendor(fn.Pkg().Path())
+	if path != "github.com/onsi/ginkgo" && !strings.HasPrefix(path, "github.com/onsi/ginkgo/v") {
+		return "", false
+	}
+
+	if lit, ok := call.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+		if text, err := strconv.Unquote(lit.Value); err == nil {
+			return text, true
+		}
+	}
+	return fn.Name(), true
+}
@@ -0,0 +1,114 @@
+package nodefertest
+
+import (
+	"go/ast"
+	"go/types"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// goStmts returns the go statements of the body in source order. The goroutines of
+// subtests, whose function literals have their own testing parameter, are left to the
+// defers of those subtests.
+func goStmts(pass *analysis.Pass, body *ast.BlockStmt) []*ast.GoStmt {
+	var found []*ast.GoStmt
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncLit:
+			return !hasFuncLitTestingTParam(pass, node)
+		case *ast.GoStmt:
+			found = append(found, node)
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// sharedWithGoroutine returns a local variable of the body released by the deferred call,
+// such as conn in defer conn.Close() or closeConn(conn), and the first of the go statements
+// using it, or nil if no goroutine uses what the call releases
+func sharedWithGoroutine(pass *analysis.Pass, body *ast.BlockStmt, stmts []*ast.GoStmt, call *ast.CallExpr) (*types.Var, *ast.GoStmt) {
+	resources := deferredResources(pass, body, call)
+	if len(resources) == 0 {
+		return nil, nil
+	}
+
+	for _, stmt := range stmts {
+		var v *types.Var
+		ast.Inspect(stmt.Call, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok && v == nil {
+				if obj, ok := pass.TypesInfo.Uses[ident].(*types.Var); ok && resources[obj] {
+					v = obj
+				}
+			}
+			return v == nil
+		})
+		if v != nil {
+			return v, stmt
+		}
+	}
+	return nil, nil
+}
+
+// releasePrefixes are the lowercase prefixes of the names of calls that release what they
+// are called on or with, such as Close, closeConn, Stop, Cancel, or os.RemoveAll
+var releasePrefixes = []string{"close", "stop", "cancel", "remove", "shutdown", "release", "delete", "destroy", "kill", "terminate"}
+
+// isReleaseCall checks if the call is named like a teardown releasing a resource. Other
+// calls, such as wg.Wait() or mu.Unlock(), are not, and a goroutine may well use what
+// they are called on.
+func isReleaseCall(call *ast.CallExpr) bool {
+	var name string
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		name = fun.Name
+	case *ast.SelectorExpr:
+		name = fun.Sel.Name
+	}
+	name = strings.ToLower(name)
+	return slices.ContainsFunc(releasePrefixes, func(prefix string) bool {
+		return strings.HasPrefix(name, prefix)
+	})
+}
+
+// deferredResources returns the variables declared in the body that the deferred call
+// releases, if it is a teardown such as Close: the receiver of a method call, as conn in
+// conn.Close() or s.conn.Close(), and the arguments of the call. Channels are left out,
+// since closing one a goroutine receives from is how it is told to stop.
+func deferredResources(pass *analysis.Pass, body *ast.BlockStmt, call *ast.CallExpr) map[*types.Var]bool {
+	if !isReleaseCall(call) {
+		return nil
+	}
+
+	exprs := slices.Clone(call.Args)
+	if sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr); ok {
+		exprs = append(exprs, sel.X)
+	}
+
+	resources := make(map[*types.Var]bool)
+	for _, expr := range exprs {
+		// The root of a selector chain, as s in s.conn
+		for {
+			sel, ok := ast.Unparen(expr).(*ast.SelectorExpr)
+			if !ok {
+				break
+			}
+			expr = sel.X
+		}
+		ident, ok := ast.Unparen(expr).(*ast.Ident)
+		if !ok {
+			continue
+		}
+		v, ok := pass.TypesInfo.Uses[ident].(*types.Var)
+		if !ok || v.Pos() < body.Pos() || v.Pos() >= body.End() {
+			continue
+		}
+		if _, ok := v.Type().Underlying().(*types.Chan); !ok {
+			resources[v] = true
+		}
+	}
+	return resources
+}
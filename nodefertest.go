@@ -1,88 +1,1437 @@
 package nodefertest
 
 import (
+	"cmp"
+	"fmt"
 	"go/ast"
+	"go/build"
+	"go/build/constraint"
+	"go/token"
+	"go/types"
+	"io"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
 )
 
-const doc = "nodefertest checks for the use of 'defer' in test functions, which can lead to unexpected behavior when functions like t.Fatal or t.FailNow are called, as they stop execution immediately and prevent deferred cleanup from running."
+const doc = "nodefertest checks for the use of 'defer' in test functions, which can lead to unexpected behavior when functions like t.Fatal or t.FailNow are called, as they stop execution immediately and prevent deferred cleanup from running. See " + url + " for the rationale behind each diagnostic."
+
+// url documents the analyzer, with an anchor for each diagnostic category
+const url = "https://github.com/s4s7/nodefertest"
 
 var Analyzer = &analysis.Analyzer{
-	Name: "nodefertest",
-	Doc:  doc,
-	Run:  run,
+	Name:       "nodefertest",
+	Doc:        doc,
+	URL:        url,
+	Run:        run,
+	Requires:   []*analysis.Analyzer{inspect.Analyzer},
+	ResultType: reflect.TypeFor[[]Diagnostic](),
+	FactTypes:  []analysis.Fact{(*deferringHelper)(nil), (*stoppingHelper)(nil)},
+}
+
+var (
+	// checkBenchmarks controls whether Benchmark functions are analyzed
+	checkBenchmarks bool
+	// allowUnlock controls whether deferred sync.Mutex/sync.RWMutex unlocks are allowed
+	allowUnlock bool
+	// noteUnlock reports allowed mutex unlocks as information
+	noteUnlock bool
+	// allowRecover controls whether deferred functions that only call recover() are allowed
+	allowRecover bool
+	// allowTimerControls controls whether deferred b.StopTimer/b.StartTimer/b.ResetTimer calls are allowed
+	allowTimerControls bool
+	// allowSpanEnd controls whether deferred calls of spanEndMethod, such as span.End(), are allowed
+	allowSpanEnd bool
+	// spanEndMethod is the name of the method ending a trace span or region
+	spanEndMethod = "End"
+	// requireFatal limits reports to test functions that may stop via t.Fatal/t.FailNow
+	requireFatal bool
+	// fatalOrder limits reports to defers followed by a call that may stop the test
+	fatalOrder bool
+	// checkExamples controls whether Example functions are analyzed
+	checkExamples bool
+	// skipOutputExamples skips examples with an output comment under checkExamples
+	skipOutputExamples bool
+	// checkHelpers controls whether non-test functions taking a testing parameter are analyzed
+	checkHelpers bool
+	// prefixes are the function name prefixes that identify test functions
+	prefixes = listFlag{"Test", "Benchmark", "Fuzz"}
+	// severity is the severity of each diagnostic in the result and JSON output
+	severity = severityFlag("error")
+	// flagGoroutineDefers controls whether goroutines receiving a *testing.T are checked
+	flagGoroutineDefers bool
+	// ignoreTBHelpers skips helpers whose only testing parameters are testing.TB under checkHelpers
+	ignoreTBHelpers bool
+	// checkTFields controls whether methods and closures of types storing a *testing.T in a field are analyzed
+	checkTFields bool
+	// allowCalls are the deferred calls that are allowed, as qualified or selector names
+	allowCalls listFlag
+	// allowPackages are the import paths of the packages whose functions may be deferred
+	allowPackages listFlag
+	// excludeFiles matches the names of files that are not analyzed
+	excludeFiles regexpFlag
+	// excludeDirs are the path substrings, such as vendor/, of the files that are not analyzed
+	excludeDirs listFlag
+	// ignoreEmptyDefers skips deferred empty function literals
+	ignoreEmptyDefers bool
+	// strictSignature only treats functions with exactly a testing parameter as tests
+	strictSignature bool
+	// respectIntentionalComment allows defers right below a comment matching intentionalComment
+	respectIntentionalComment bool
+	// intentionalComment matches the comments explaining an intentional defer
+	intentionalComment = regexpFlag{regexp.MustCompile(`(?i)intentional`)}
+	// onlyTestFiles limits the checked test functions to _test.go files
+	onlyTestFiles bool
+	// minGo is the Go version the code must build with, overriding the go directive when set
+	minGo string
+	// ignoreUnderShortGuard allows defers that are guarded by testing.Short()
+	ignoreUnderShortGuard bool
+	// checkGinkgo checks the closures passed to Ginkgo nodes such as It and BeforeEach
+	checkGinkgo bool
+	// optIn only checks the files with the opt-in marker comment
+	optIn bool
+	// printFixes writes the suggested fixes to standard output instead of reporting them
+	printFixes bool
+	// summary reports the number of diagnostics per test function at the end of each package
+	summary bool
+	// methodNames are the names of the methods that runners call as the body of a test,
+	// which are analyzed when they take a testing parameter; none by default
+	methodNames listFlag
+	// maxDepth is the number of nested test and subtest functions checked, or 0 for no limit
+	maxDepth int
+	// excludeFuncs are the names of the test functions that are not analyzed
+	excludeFuncs listFlag
+	// config is the path of the configuration file loaded into the other flags
+	config configFlag
+	// message is the template of the message for defers in test functions, unset for the default
+	message templateFlag
+	// explain writes why each function declaration is checked or not to standard error
+	explain bool
+)
+
+func init() {
+	Analyzer.Flags.BoolVar(&checkBenchmarks, "check-benchmarks", true,
+		"check Benchmark functions for defer; when false they are ignored entirely")
+	Analyzer.Flags.BoolVar(&allowUnlock, "allow-unlock", true,
+		"allow deferred Unlock/RUnlock of a sync.Mutex or sync.RWMutex")
+	Analyzer.Flags.BoolVar(&noteUnlock, "note-unlock", false,
+		"report allowed deferred unlocks as information explaining why they are kept, without a fix")
+	Analyzer.Flags.BoolVar(&allowRecover, "allow-recover", true,
+		"allow deferred function literals that only recover from a panic")
+	Analyzer.Flags.BoolVar(&allowTimerControls, "allow-timer-controls", true,
+		"allow deferred b.StopTimer, b.StartTimer, and b.ResetTimer calls in benchmarks")
+	Analyzer.Flags.BoolVar(&allowSpanEnd, "allow-span-end", false,
+		"allow deferred calls of the -span-end-method method, such as span.End() or trace.StartRegion(ctx, \"x\").End()")
+	Analyzer.Flags.StringVar(&spanEndMethod, "span-end-method", "End",
+		"name of the method ending a trace span or region, used with -allow-span-end")
+	Analyzer.Flags.BoolVar(&requireFatal, "require-fatal", false,
+		"only report defer in test functions that call t.Fatal, t.FailNow, require.*, or a helper taking t that may call them")
+	Analyzer.Flags.BoolVar(&fatalOrder, "fatal-order", false,
+		"only report defer followed by a call that may stop the test, naming the call; a defer after the last such call cannot be skipped")
+	Analyzer.Flags.BoolVar(&checkExamples, "check-examples", false,
+		"check Example functions for defer even though they have no testing parameter")
+	Analyzer.Flags.BoolVar(&skipOutputExamples, "skip-output-examples", false,
+		"with -check-examples, skip examples with an // Output: comment, which go test runs and checks as documentation")
+	Analyzer.Flags.BoolVar(&checkHelpers, "check-helpers", false,
+		"also check helper functions that take a *testing.T, *testing.B, or *testing.F parameter")
+	Analyzer.Flags.BoolVar(&ignoreTBHelpers, "ignore-tb-helpers", false,
+		"with -check-helpers, skip helpers taking only testing.TB, which are shared by tests and benchmarks")
+	Analyzer.Flags.Var(&prefixes, "prefixes",
+		"comma-separated function name prefixes that identify test functions")
+	Analyzer.Flags.Var(&severity, "severity",
+		"severity of the diagnostics in the analyzer result and JSON output: error, warning, or info")
+	Analyzer.Flags.BoolVar(&flagGoroutineDefers, "flag-goroutine-defers", false,
+		"check defer in goroutines started with go func(t *testing.T) {...}(t)")
+	Analyzer.Flags.BoolVar(&checkTFields, "check-t-fields", false,
+		"also check methods whose receiver struct has a *testing.T, *testing.B, *testing.F, or testing.TB field, embedded or not, and closures in tests taking such a struct")
+	Analyzer.Flags.Var(&allowCalls, "allow-calls",
+		"comma-separated deferred calls to allow, as qualified names like os.RemoveAll or (*os.File).Close, or selectors like f.Close")
+	Analyzer.Flags.Var(&allowPackages, "allow-packages",
+		"comma-separated import paths of packages whose functions and methods may be deferred, such as idempotent teardown helpers")
+	Analyzer.Flags.Var(&excludeFiles, "exclude-files",
+		"regexp matching the names of files to skip; files with a build constraint on the "+ignoreBuildTag+" tag are always skipped")
+	Analyzer.Flags.Var(&excludeDirs, "exclude-dirs",
+		"comma-separated substrings of the slash-separated paths of files to skip, such as vendor/ or third_party/ for copied code")
+	Analyzer.Flags.BoolVar(&ignoreEmptyDefers, "ignore-empty-defers", false,
+		"allow deferred empty function literals such as defer func() {}()")
+	Analyzer.Flags.BoolVar(&strictSignature, "strict-signature", false,
+		"only treat functions as tests when their signature is exactly func(*testing.T), as go test requires, not parameterized helpers like TestFoo(t *testing.T, n int)")
+	Analyzer.Flags.BoolVar(&respectIntentionalComment, "respect-intentional-comment", false,
+		"allow defers right below a comment matching -intentional-comment, such as // intentional defer: restores env before return")
+	Analyzer.Flags.Var(&intentionalComment, "intentional-comment",
+		"regexp matching the comments that explain an intentional defer, used with -respect-intentional-comment")
+	Analyzer.Flags.BoolVar(&onlyTestFiles, "only-test-files", true,
+		"only check test functions in _test.go files, since a TestXxx function elsewhere is not run by go test")
+	Analyzer.Flags.StringVar(&minGo, "min-go", "",
+		"oldest Go version, such as 1.13, the code must build with; no fix is suggested below 1.14, which added Cleanup, and loop variables captured by deferred functions are only reported below 1.22. Defaults to the go directive of the module")
+	Analyzer.Flags.BoolVar(&ignoreUnderShortGuard, "ignore-under-short-guard", false,
+		"allow defers in an if statement on testing.Short(), or after an if testing.Short() { return } guard")
+	Analyzer.Flags.BoolVar(&checkGinkgo, "ginkgo", false,
+		"also check the closures passed to Ginkgo nodes such as It, BeforeEach, and AfterEach, whose failures stop the node")
+	Analyzer.Flags.BoolVar(&optIn, "opt-in", false,
+		"only check files that opt in with a "+optInMarker+" comment, for an incremental rollout")
+	Analyzer.Flags.BoolVar(&printFixes, "print-fixes", false,
+		"write the suggested fixes to standard output, grouped by file, for review instead of attaching them to the diagnostics")
+	Analyzer.Flags.BoolVar(&summary, "summary", false,
+		"report a summary of the diagnostics per test function on the package clause of each package")
+	Analyzer.Flags.Var(&methodNames, "method-names",
+		"comma-separated names of methods, such as Run, that test runners call as the test body; methods with these names taking a *testing.T, *testing.B, or *testing.F are also checked. Empty by default, checking no methods")
+	Analyzer.Flags.IntVar(&maxDepth, "max-depth", 1000,
+		"maximum nesting of checked function literals, such as subtests, in a test function; deeper literals are reported once and skipped. 0 means no limit")
+	Analyzer.Flags.Var(&excludeFuncs, "exclude-functions",
+		"comma-separated names of test functions to skip, along with their subtests")
+	Analyzer.Flags.Var(&config, "config",
+		"path of a YAML file, such as .nodefertest.yaml, setting flags by name; flags given on the command line take precedence")
+	Analyzer.Flags.Var(&message, "message",
+		"text/template for the message about defers in test functions, with {{.FuncName}}, {{.CleanupName}}, and {{.Message}} for the default message")
+	Analyzer.Flags.BoolVar(&explain, "explain", false,
+		"write to standard error why each function declaration is checked or skipped, to diagnose configurations; not named -debug, which the standalone driver already defines")
+	trackFlags()
+}
+
+// listFlag is a comma-separated list flag
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(s string) error {
+	*l = nil
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// regexpFlag is a regular expression flag, empty when unset
+type regexpFlag struct {
+	re *regexp.Regexp
+}
+
+func (r *regexpFlag) String() string {
+	if r.re == nil {
+		return ""
+	}
+	return r.re.String()
+}
+
+func (r *regexpFlag) Set(s string) error {
+	if s == "" {
+		r.re = nil
+		return nil
+	}
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return err
+	}
+	r.re = re
+	return nil
+}
+
+// templateFlag is a text/template flag, parsed when it is set and empty when unset
+type templateFlag struct {
+	text string
+	tmpl *template.Template
+}
+
+func (f *templateFlag) String() string {
+	return f.text
+}
+
+func (f *templateFlag) Set(s string) error {
+	if s == "" {
+		*f = templateFlag{}
+		return nil
+	}
+	tmpl, err := template.New("message").Option("missingkey=error").Parse(s)
+	if err != nil {
+		return err
+	}
+	// Unknown fields only fail on execution, so try it once here
+	if err := tmpl.Execute(io.Discard, messageData{}); err != nil {
+		return err
+	}
+	*f = templateFlag{text: s, tmpl: tmpl}
+	return nil
+}
+
+// messageData holds the placeholders of the -message template
+type messageData struct {
+	// FuncName is the name of the enclosing test function declaration
+	FuncName string
+	// CleanupName is the Cleanup method to use instead, such as t.Cleanup
+	CleanupName string
+	// Message is the default message
+	Message string
+}
+
+// renderMessage returns the message for a defer in test function ctx, from the
+// template tmpl if set, or the default message msg otherwise
+func renderMessage(tmpl *template.Template, ctx *testContext, msg string) string {
+	msg = fmt.Sprintf("%s in test function %q", msg, ctx.name)
+	if tmpl == nil {
+		return msg
+	}
+
+	tName := ctx.tName
+	if tName == "" {
+		tName = "t"
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, messageData{FuncName: ctx.name, CleanupName: tName + ".Cleanup", Message: msg}); err != nil {
+		return msg
+	}
+	return b.String()
+}
+
+// severityFlag is the severity of the diagnostics, one of error, warning, or info
+type severityFlag string
+
+func (s *severityFlag) String() string {
+	return string(*s)
+}
+
+func (s *severityFlag) Set(v string) error {
+	switch v {
+	case "error", "warning", "info":
+		*s = severityFlag(v)
+		return nil
+	}
+	return fmt.Errorf("invalid severity %q: must be error, warning, or info", v)
+}
+
+// diagnose completes the diagnostic d for the test function ctx with its position and
+// the configured severity; check reports it
+func diagnose(pass *analysis.Pass, opts *Options, ctx *testContext, d Diagnostic) Diagnostic {
+	pos := pass.Fset.Position(d.Pos)
+	d.File = pos.Filename
+	d.Line = pos.Line
+	d.Column = pos.Column
+	d.TestName = ctx.name
+	d.Severity = opts.Severity
+	d.URL = url + "#" + categories[d.Kind]
+	return d
+}
+
+// testContext is a function whose defer statements are checked
+type testContext struct {
+	node ast.Node // *ast.FuncDecl or *ast.FuncLit
+	body *ast.BlockStmt
+	// name is the name of the enclosing test function declaration
+	name string
+	// tName is the name of the *testing.T parameter in scope, used to build suggested fixes
+	tName string
+	// example is set for Example functions, which have no testing parameter
+	example bool
+	// cleanup is set for function literals passed to t.Cleanup
+	cleanup bool
+	// ginkgo is set for function literals passed to Ginkgo nodes such as It, which have
+	// no testing parameter; name is then the name of the node
+	ginkgo bool
+	// goroutine is set for function literals started by a go statement, under -flag-goroutine-defers
+	goroutine bool
+	// defers counts the defer statements in the function itself that are neither allowed,
+	// ignored, nor left as is under a short guard, whose order relative to t.Cleanup matters
+	defers int
+
+	facts *bodyFacts
+}
+
+// bodyFacts are what the defers of a test function are checked against, gathered from
+// its body once for all of them
+type bodyFacts struct {
+	// stops are the calls that may stop the test, such as t.Fatal, in source order
+	stops []*ast.CallExpr
+	// parallels are the t.Parallel() calls outside nested function literals, in source order
+	parallels []*ast.CallExpr
+	// parallelSubtest is set if the function starts subtests that call t.Parallel,
+	// which only resume after the function has returned and run its defers
+	parallelSubtest bool
+	// goStmts are the go statements outside subtests, in source order
+	goStmts []*ast.GoStmt
+	// setups are the setup calls the variables of the body are bound to, for teardowns
+	setups map[*types.Var]*ast.CallExpr
+	// assigned are the positions of the last assignments to the variables
+	assigned map[*types.Var]token.Pos
+}
+
+// bodyFacts returns the facts about the body of the function, gathered the first time
+// one of its defers is checked. subtests are the functions of the package run by name
+// with t.Run.
+func (c *testContext) bodyFacts(pass *analysis.Pass, subtests map[types.Object]*ast.FuncDecl) *bodyFacts {
+	if c.facts == nil {
+		c.facts = &bodyFacts{
+			stops:           fatalCalls(pass, c.body),
+			parallels:       parallelCalls(pass, c.body),
+			parallelSubtest: hasParallelSubtest(pass, c.body, subtests),
+			goStmts:         goStmts(pass, c.body),
+			setups:          setupCalls(pass, c.body),
+			assigned:        lastAssignments(pass, c.body),
+		}
+	}
+	return c.facts
 }
 
 func run(pass *analysis.Pass) (any, error) {
-	// Iterate over all files
+	diags, err := RunWithOptions(pass, flagOptions())
+	if err != nil {
+		return nil, err
+	}
+	return diags, nil
+}
+
+// CheckFile reports the defers in the test functions of a single type-checked file,
+// outside of the analysis framework. info must at least record Types, Defs and Uses.
+// Only the helpers declared in the file itself are known to defer. The file is checked
+// with the options set by the flags of Analyzer.
+func CheckFile(fset *token.FileSet, file *ast.File, info *types.Info) []Diagnostic {
+	opts := flagOptions()
+	return checkFile(fset, file, info, &opts)
+}
+
+// CheckFileWithOptions is like CheckFile, but checks the file with opts instead of
+// the flags of Analyzer. It returns an error if opts is invalid.
+func CheckFileWithOptions(fset *token.FileSet, file *ast.File, info *types.Info, opts Options) ([]Diagnostic, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	return checkFile(fset, file, info, &opts), nil
+}
+
+// checkFile reports the defers in the test functions of file, as described by CheckFile
+func checkFile(fset *token.FileSet, file *ast.File, info *types.Info, opts *Options) []Diagnostic {
+	files := []*ast.File{file}
+	type factKey struct {
+		obj types.Object
+		typ reflect.Type
+	}
+	facts := make(map[factKey]analysis.Fact)
+	pass := &analysis.Pass{
+		Analyzer:  Analyzer,
+		Fset:      fset,
+		Files:     files,
+		TypesInfo: info,
+		Report:    func(analysis.Diagnostic) {},
+		ImportObjectFact: func(obj types.Object, fact analysis.Fact) bool {
+			f, ok := facts[factKey{obj, reflect.TypeOf(fact)}]
+			if ok {
+				reflect.ValueOf(fact).Elem().Set(reflect.ValueOf(f).Elem())
+			}
+			return ok
+		},
+		ExportObjectFact: func(obj types.Object, fact analysis.Fact) {
+			facts[factKey{obj, reflect.TypeOf(fact)}] = fact
+		},
+	}
+	return check(pass, opts, inspector.New(files))
+}
+
+// check walks the files of the pass and reports the defers found in test functions
+func check(pass *analysis.Pass, opts *Options, inspect *inspector.Inspector) []Diagnostic {
+	nodeFilter := []ast.Node{
+		(*ast.FuncDecl)(nil),
+		(*ast.FuncLit)(nil),
+		(*ast.DeferStmt)(nil),
+		(*ast.CallExpr)(nil),
+	}
+
+	ignored := make(map[*ast.File]map[int]bool)
+	ignoredIn := func(f *ast.File) map[int]bool {
+		if _, ok := ignored[f]; !ok {
+			ignored[f] = ignoredLines(pass, opts, f)
+		}
+		return ignored[f]
+	}
+
+	// excluded holds why the test functions of a file are not checked
+	excluded := make(map[*ast.File]string)
+	var files []*ast.File
 	for _, f := range pass.Files {
-		ast.Inspect(f, func(n ast.Node) bool {
-			funcDecl, ok := n.(*ast.FuncDecl)
+		switch {
+		case isExcludedFile(pass, opts, f):
+			excluded[f] = "the file is excluded by -exclude-files, -exclude-dirs, or the ignore build tag"
+		case opts.OnlyTestFiles && !isTestFile(pass, f):
+			// Helpers in these files are still marked, but their tests are not checked
+			excluded[f] = "not in a _test.go file, with -only-test-files"
+			files = append(files, f)
+		case opts.OptIn && !hasOptInMarker(f):
+			excluded[f] = "the file has no " + optInMarker + " comment, with -opt-in"
+			files = append(files, f)
+		default:
+			files = append(files, f)
+		}
+	}
+
+	// Mark the helpers of this package that may stop the test or defer before the test
+	// is checked, so that calls to them are reported no matter the order of declarations
+	exportStopFacts(pass, opts)
+	exportHelperFacts(pass, opts, files, ignoredIn)
+	subtests := namedSubtests(pass, files)
+
+	// contexts is the stack of enclosing functions being checked; functions that
+	// are not checked are pruned, so the innermost function is always the last one
+	var contexts []*testContext
+	var diags []Diagnostic
+	reported := make(map[token.Pos]bool)
+	// explained lists why each function declaration was checked or not, under -explain.
+	// Dependencies, which drivers only analyze for their facts, are left out, as are the
+	// files whose functions are never checked.
+	var explained []string
+	explainPkg := opts.Explain && !isDependency(pass)
+	explain := func(file *ast.File, funcDecl *ast.FuncDecl, why string) {
+		if !explainPkg || opts.OnlyTestFiles && !isTestFile(pass, file) {
+			return
+		}
+		explained = append(explained, fmt.Sprintf("%v: %s: %s", pass.Fset.Position(funcDecl.Name.Pos()), funcDecl.Name.Name, why))
+	}
+
+	// Walk the whole package once
+	inspect.WithStack(nodeFilter, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			if len(contexts) > 0 && contexts[len(contexts)-1].node == n {
+				if d, ok := checkMixedCleanup(pass, opts, ignoredIn(stack[0].(*ast.File)), contexts[len(contexts)-1]); ok {
+					diags = append(diags, d)
+				}
+				contexts = contexts[:len(contexts)-1]
+			}
+			return true
+		}
+
+		switch node := n.(type) {
+		case *ast.FuncDecl:
+			file := stack[0].(*ast.File)
+			if why := excluded[file]; why != "" {
+				explain(file, node, "skipped: "+why)
+				return false
+			}
+			// Functions implemented in assembly, or malformed input, have no body to check
+			if node.Body == nil {
+				explain(file, node, "skipped: no body")
+				return false
+			}
+			ctx, why := funcDeclContext(pass, opts, file, node, subtests)
+			if ctx == nil {
+				explain(file, node, "skipped: "+why)
+				// Not a test function; nothing inside is checked, except for Ginkgo nodes
+				return opts.Ginkgo
+			}
+			explain(file, node, "checked: "+why)
+			contexts = append(contexts, ctx)
+		case *ast.FuncLit:
+			ctx, ok := funcLitContext(pass, opts, node, stack, contexts)
 			if !ok {
+				return false
+			}
+			if ctx == nil {
+				// Keep looking for Ginkgo nodes in containers such as Describe
 				return true
 			}
-
-			// Check if this is a test function
-			if !isTestFunction(funcDecl) || !hasTestingTParam(funcDecl) {
+			// Generated code can nest literals arbitrarily deep; the rest of the
+			// test function is still checked
+			if opts.MaxDepth > 0 && len(contexts) > opts.MaxDepth {
+				diags = append(diags, depthDiagnostic(pass, opts, contexts[len(contexts)-1], node))
+				return false
+			}
+			contexts = append(contexts, ctx)
+		case *ast.DeferStmt:
+			if len(contexts) == 0 {
+				return true // Only reached outside of test functions with -ginkgo
+			}
+			ctx := contexts[len(contexts)-1]
+			// Expensive setup often only runs in long mode, and is left as is under the flag
+			if opts.IgnoreUnderShortGuard && isUnderShortGuard(pass, stack, ctx.node) {
 				return true
 			}
+			if !isAllowedDefer(pass, opts, ignoredIn(stack[0].(*ast.File)), node) {
+				ctx.defers++
+			}
+			if d, ok := checkDeferInTestFunc(pass, opts, ctx, ignoredIn(stack[0].(*ast.File)), reported, subtests, node, enclosingLoops(stack, ctx.node)); ok {
+				diags = append(diags, d)
+			}
+		case *ast.CallExpr:
+			if len(contexts) == 0 || contexts[len(contexts)-1].example {
+				return true
+			}
+			if d, ok := checkHelperCall(pass, opts, contexts[len(contexts)-1], ignoredIn(stack[0].(*ast.File)), node); ok {
+				diags = append(diags, d)
+			}
+			if d, ok := checkCleanupFatal(pass, opts, contexts[len(contexts)-1], ignoredIn(stack[0].(*ast.File)), node); ok {
+				diags = append(diags, d)
+			}
+		}
+		return true
+	})
 
-			// Check defer statements in this test function
-			checkDeferInTestFunc(pass, funcDecl.Body)
-			return false // Don't traverse into the function body again
-		})
+	// Report in position order whatever the order of the walk, so the output is reproducible
+	slices.SortStableFunc(diags, func(a, b Diagnostic) int {
+		return cmp.Compare(a.Pos, b.Pos)
+	})
+	for _, d := range diags {
+		pass.Report(d.analysisDiagnostic(opts))
+	}
+	if opts.Explain {
+		writeExplanations(explainOutput, explained)
 	}
 
-	return nil, nil
+	return diags
 }
 
-// checkDeferInTestFunc recursively checks for defer statements in test functions
-func checkDeferInTestFunc(pass *analysis.Pass, body *ast.BlockStmt) {
-	ast.Inspect(body, func(n ast.Node) bool {
-		switch node := n.(type) {
-		case *ast.DeferStmt:
-			pass.Reportf(node.Defer,
-				"use t.Cleanup() instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow")
+// funcDeclContext returns the context for a function declaration whose defers are checked,
+// or nil if it is neither a test function, a function run by name with t.Run, nor otherwise
+// selected by the flags. It also returns why the function is checked or not, for -explain.
+func funcDeclContext(pass *analysis.Pass, opts *Options, file *ast.File, funcDecl *ast.FuncDecl, subtests map[types.Object]*ast.FuncDecl) (*testContext, string) {
+	if slices.Contains(opts.ExcludeFunctions, funcDecl.Name.Name) {
+		return nil, "listed in -exclude-functions"
+	}
+
+	// The testing parameter is only required of the kinds of functions that take one
+	tName := testingParamName(pass, funcDecl.Type.Params)
+	isTest := false
+	// byName reports whether why is about the name alone, as opposed to the signature
+	why, byName := notTestReason(opts, funcDecl), true
+	switch testFuncKind(opts, funcDecl) {
+	case exampleFunc:
+		if opts.SkipOutputExamples && hasOutputComment(file, funcDecl.Body) {
+			return nil, "an example with an output comment, with -skip-output-examples"
+		}
+		return &testContext{node: funcDecl, body: funcDecl.Body, name: funcDecl.Name.Name, example: true}, "an example function, with -check-examples"
+	case testFunc:
+		hasParam := hasTestingTParam(pass, funcDecl)
+		byName = false
+		why = "a test function name without a *testing.T, *testing.B, *testing.F, or testing.TB parameter"
+		if opts.StrictSignature {
+			hasParam = hasTestSignature(pass, funcDecl)
+			why = "a test function name with a signature other than func(*testing.T), with -strict-signature"
+		}
+		switch {
+		case hasParam:
+			isTest, why = true, "a test function"
+		case isSuiteMethod(pass, funcDecl):
+			isTest, why = true, "a test method of a testify suite"
+		}
+	}
+
+	// Otherwise check if this is a subtest or a helper receiving *testing.T
+	hasParam := hasTestingTParam(pass, funcDecl)
+	switch {
+	case isTest:
+	case subtests[pass.TypesInfo.Defs[funcDecl.Name]] != nil && hasParam:
+		isTest, why = true, "a subtest function run by name with t.Run"
+	case isRunnerMethod(pass, opts.MethodNames, funcDecl):
+		isTest, why = true, "a runner method named in -method-names"
+	case opts.CheckHelpers && hasParam && !(opts.IgnoreTBHelpers && hasOnlyTBParams(pass, funcDecl)):
+		why = "a helper taking a testing parameter, with -check-helpers"
+	default:
+		// Methods of fixtures that call f.t.Fatal can stop the test just the same
+		field, ok := testingField(pass, funcDecl)
+		if !opts.CheckTFields || !ok {
+			if hasParam && byName {
+				why += "; it takes a testing parameter, but helpers are only checked with -check-helpers"
+			}
+			return nil, why
+		}
+		tName, why = field, "a method of a type with a testing field, with -check-t-fields"
+	}
+
+	return &testContext{
+		node:  funcDecl,
+		body:  funcDecl.Body,
+		name:  funcDecl.Name.Name,
+		tName: tName,
+	}, why
+}
+
+// isRunnerMethod checks if the function is a method named one of names, as listed in -method-names,
+// with a testing parameter, such as func (r Runner) Run(t *testing.T), which a runner calls as a test
+func isRunnerMethod(pass *analysis.Pass, names []string, funcDecl *ast.FuncDecl) bool {
+	if funcDecl.Recv == nil || !slices.Contains(names, funcDecl.Name.Name) {
+		return false
+	}
+	return hasTestingTParam(pass, funcDecl)
+}
+
+// testingField checks if the method's receiver struct has a *testing.T field, and returns
+// the selector for it, such as "f.t", or "" if the receiver is unnamed. Value and pointer
+// receivers are treated alike.
+func testingField(pass *analysis.Pass, funcDecl *ast.FuncDecl) (string, bool) {
+	if funcDecl.Recv == nil || len(funcDecl.Recv.List) == 0 {
+		return "", false
+	}
+	recv := funcDecl.Recv.List[0]
+
+	typ := pass.TypesInfo.TypeOf(recv.Type)
+	if typ == nil {
+		return "", false
+	}
+	path, ok := testingFieldPath(typ, nil)
+	if !ok {
+		return "", false
+	}
+	if len(recv.Names) == 0 || recv.Names[0].Name == "_" {
+		return "", true
+	}
+	return recv.Names[0].Name + "." + path, true
+}
+
+// testingFieldParam checks if one of the parameters is a struct with a *testing.T field,
+// such as x struct{ *testing.T }, and returns the selector for it, such as "x.T", or ""
+// if the parameter is unnamed
+func testingFieldParam(pass *analysis.Pass, params *ast.FieldList) (string, bool) {
+	if params == nil {
+		return "", false
+	}
+	for _, field := range params.List {
+		typ := pass.TypesInfo.TypeOf(field.Type)
+		if typ == nil {
+			continue
+		}
+		path, ok := testingFieldPath(typ, nil)
+		if !ok {
+			continue
+		}
+		if len(field.Names) == 0 || field.Names[0].Name == "_" {
+			return "", true
+		}
+		return field.Names[0].Name + "." + path, true
+	}
+	return "", false
+}
+
+// testingFieldPath returns the path of the fields leading to a *testing.T, *testing.B,
+// *testing.F, or testing.TB field of the struct typ, or of a pointer to it, such as "t",
+// or "T" for an embedded *testing.T. Embedded structs are searched too, as in "base.t";
+// seen holds the structs already searched, since embedded pointers may form a cycle.
+func testingFieldPath(typ types.Type, seen map[types.Type]bool) (string, bool) {
+	if ptr, ok := types.Unalias(typ).(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	st, ok := typ.Underlying().(*types.Struct)
+	if !ok || seen[typ] {
+		return "", false
+	}
+
+	for field := range st.Fields() {
+		if isTestingParamType(field.Type()) {
+			return field.Name(), true
+		}
+	}
+	if seen == nil {
+		seen = make(map[types.Type]bool)
+	}
+	seen[typ] = true
+	for field := range st.Fields() {
+		if !field.Embedded() {
+			continue
+		}
+		if path, ok := testingFieldPath(field.Type(), seen); ok {
+			return field.Name() + "." + path, true
+		}
+	}
+	return "", false
+}
+
+// isGoroutine checks if the innermost node of the stack is the function started by a go statement
+func isGoroutine(stack []ast.Node) bool {
+	if len(stack) < 3 {
+		return false
+	}
+	call, ok := stack[len(stack)-2].(*ast.CallExpr)
+	if !ok || call.Fun != stack[len(stack)-1] {
+		return false
+	}
+	_, ok = stack[len(stack)-3].(*ast.GoStmt)
+	return ok
+}
+
+// isCleanupFunc checks if the innermost node of the stack is a function literal passed
+// directly to t.Cleanup, b.Cleanup, or f.Cleanup
+func isCleanupFunc(pass *analysis.Pass, stack []ast.Node) bool {
+	if len(stack) < 2 {
+		return false
+	}
+	call, ok := stack[len(stack)-2].(*ast.CallExpr)
+	if !ok || !slices.Contains(call.Args, ast.Expr(stack[len(stack)-1].(*ast.FuncLit))) {
+		return false
+	}
+	return isTestingMethod(pass, call, "Cleanup")
+}
+
+// enclosingLoops returns the for and range loops of the function fn, which is an
+// element of the stack, that enclose the innermost node of the stack
+func enclosingLoops(stack []ast.Node, fn ast.Node) []ast.Stmt {
+	var loops []ast.Stmt
+	for i := len(stack) - 2; i >= 0 && stack[i] != fn; i-- {
+		switch loop := stack[i].(type) {
+		case *ast.ForStmt:
+			loops = append(loops, loop)
+		case *ast.RangeStmt:
+			loops = append(loops, loop)
+		}
+	}
+	return loops
+}
+
+// isBenchmarkLoop checks if one of the loops runs the iterations of a benchmark, as in
+// for i := 0; i < b.N; i++, for range b.N, or for b.Loop()
+func isBenchmarkLoop(pass *analysis.Pass, loops []ast.Stmt) bool {
+	for _, loop := range loops {
+		var header ast.Expr
+		switch loop := loop.(type) {
+		case *ast.ForStmt:
+			header = loop.Cond
+		case *ast.RangeStmt:
+			header = loop.X
+		}
+		if header != nil && refersToBenchmarkN(pass, header) {
 			return true
+		}
+	}
+	return false
+}
+
+// refersToBenchmarkN checks if the expression uses the N field of a *testing.B or calls b.Loop()
+func refersToBenchmarkN(pass *analysis.Pass, expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		switch node := n.(type) {
 		case *ast.FuncLit:
-			// Check if this function literal has a *testing.T parameter
-			if hasFuncLitTestingTParam(node) {
-				// Recursively check this function literal
-				checkDeferInTestFunc(pass, node.Body)
+			return false
+		case *ast.SelectorExpr:
+			if v, ok := pass.TypesInfo.ObjectOf(node.Sel).(*types.Var); ok && v.IsField() && v.Name() == "N" && v.Pkg() != nil && v.Pkg().Path() == "testing" {
+				found = true
 			}
-			// Don't traverse into this function literal from here
-			// (we already handled it above if it has *testing.T param)
+		case *ast.CallExpr:
+			found = isTestingMethod(pass, node, "Loop")
+		}
+		return !found
+	})
+	return found
+}
+
+// capturedLoopVar returns the name of a variable declared by one of the loops that the
+// deferred function literal refers to, or "" if there is none
+func capturedLoopVar(pass *analysis.Pass, loops []ast.Stmt, call *ast.CallExpr) string {
+	lit, ok := call.Fun.(*ast.FuncLit)
+	if !ok {
+		return ""
+	}
+
+	vars := make(map[types.Object]bool)
+	addVars := func(exprs ...ast.Expr) {
+		for _, expr := range exprs {
+			if ident, ok := expr.(*ast.Ident); ok {
+				if obj := pass.TypesInfo.Defs[ident]; obj != nil {
+					vars[obj] = true
+				}
+			}
+		}
+	}
+	for _, loop := range loops {
+		switch loop := loop.(type) {
+		case *ast.ForStmt:
+			if init, ok := loop.Init.(*ast.AssignStmt); ok && init.Tok == token.DEFINE {
+				addVars(init.Lhs...)
+			}
+		case *ast.RangeStmt:
+			if loop.Tok == token.DEFINE {
+				addVars(loop.Key, loop.Value)
+			}
+		}
+	}
+
+	captured := ""
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && vars[pass.TypesInfo.Uses[ident]] {
+			captured = ident.Name
+		}
+		return captured == ""
+	})
+	return captured
+}
+
+// checkDeferInTestFunc checks a defer statement directly inside the test function ctx.
+// loops are the loops enclosing the defer, where deferred calls also pile up.
+// reported records the defers already reported, so that each is reported once
+// however the walk reaches it, and subtests are the functions of the package run by
+// name with t.Run. It returns the reported diagnostic, if any.
+func checkDeferInTestFunc(pass *analysis.Pass, opts *Options, ctx *testContext, ignored map[int]bool, reported map[token.Pos]bool, subtests map[types.Object]*ast.FuncDecl, deferStmt *ast.DeferStmt, loops []ast.Stmt) (Diagnostic, bool) {
+	if reported[deferStmt.Defer] {
+		return Diagnostic{}, false
+	}
+	d, ok := deferDiagnostic(pass, opts, ctx, ignored, subtests, deferStmt, loops)
+	if ok {
+		reported[deferStmt.Defer] = true
+	}
+	return d, ok
+}
+
+// deferDiagnostic returns the diagnostic for a defer statement in the test function ctx, if any
+func deferDiagnostic(pass *analysis.Pass, opts *Options, ctx *testContext, ignored map[int]bool, subtests map[types.Object]*ast.FuncDecl, deferStmt *ast.DeferStmt, loops []ast.Stmt) (Diagnostic, bool) {
+	if isAllowedDefer(pass, opts, ignored, deferStmt) {
+		// Allowed unlocks look like any other defer, so explain why they are kept
+		if opts.NoteUnlock && opts.AllowUnlock && !ignored[pass.Fset.PositionFor(deferStmt.Defer, false).Line] && isMutexUnlock(pass, deferStmt.Call) {
+			d := diagnose(pass, opts, ctx, Diagnostic{
+				Kind:    KindUnlock,
+				Pos:     deferStmt.Defer,
+				End:     deferStmt.Call.End(),
+				Message: fmt.Sprintf("deferred unlock is kept: unlocking with t.Cleanup would hold the lock until the test and its subtests have completed, and the lock is released even after t.Fatal/t.FailNow, in test function %q", ctx.name),
+			})
+			d.Severity = "info"
+			return d, true
+		}
+		return Diagnostic{}, false
+	}
+
+	if ctx.example {
+		return diagnose(pass, opts, ctx, Diagnostic{
+			Kind:    KindExample,
+			Pos:     deferStmt.Defer,
+			End:     deferStmt.Call.End(),
+			Message: fmt.Sprintf("avoid defer in example functions; examples run as part of the test binary and deferred calls are skipped if the example exits early in example function %q", ctx.name),
+		}), true
+	}
+
+	if ctx.ginkgo {
+		return diagnose(pass, opts, ctx, Diagnostic{
+			Kind:    KindGinkgo,
+			Pos:     deferStmt.Defer,
+			End:     deferStmt.Call.End(),
+			Message: fmt.Sprintf("use DeferCleanup() instead of defer in Ginkgo nodes to ensure cleanup runs even after a failed assertion stops the node in Ginkgo node %q", ctx.name),
+		}), true
+	}
+
+	if ctx.cleanup {
+		return diagnose(pass, opts, ctx, Diagnostic{
+			Kind:    KindCleanupFunc,
+			Pos:     deferStmt.Defer,
+			End:     deferStmt.Call.End(),
+			Message: fmt.Sprintf("avoid defer in functions registered with t.Cleanup; call it at the end of the cleanup function, or register it with its own t.Cleanup, which runs after this one, in test function %q", ctx.name),
+		}), true
+	}
+
+	msg := "use t.Cleanup() instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+	kind := KindDefer
+	if ctx.goroutine {
+		kind = KindGoroutine
+	}
+	tName := ctx.tName
+	facts := ctx.bodyFacts(pass, subtests)
+	stop := stopAfter(facts.stops, deferStmt, loops)
+	related := stopRelated(stop)
+	result := assignedResult(pass, ctx.node, deferStmt.Call)
+	teardown, setup := boundTeardown(pass, ctx.body, facts.setups, deferStmt.Call)
+	parallel := callAfter(facts.parallels, deferStmt.End())
+	shared, goStmt := sharedWithGoroutine(pass, ctx.body, facts.goStmts, deferStmt.Call)
+	switch {
+	case facts.parallelSubtest:
+		// Deferred calls run before the parallel subtests, whether or not anything stops early
+		kind = KindParallel
+		msg = "use t.Cleanup() instead of defer in test functions with parallel subtests; deferred calls run when the function returns, before the subtests calling t.Parallel() have completed"
+	case parallel != nil:
+		// The test pauses in t.Parallel() while its parent goes on, whether or not anything stops early
+		kind = KindSelfParallel
+		msg = "use t.Cleanup() instead of defer before t.Parallel(); t.Parallel() pauses the test until its parent test function has returned, so resources the deferred call releases may be shared with tests that outlive the pause, and the call is skipped after t.Fatal/t.FailNow"
+		related = append([]RelatedInformation{{
+			Pos:     parallel.Pos(),
+			End:     parallel.End(),
+			Message: "the test pauses here until its parent test function has returned",
+		}}, related...)
+	case opts.RequireFatal && len(facts.stops) == 0, opts.FatalOrder && stop == nil:
+		// A defer is only at risk if something in this test function can stop it early
+		return Diagnostic{}, false
+	case shared != nil:
+		kind = KindSharedGo
+		msg = fmt.Sprintf("use t.Cleanup() instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow, and wait for the goroutine started on line %d to finish before releasing %q, which it still uses; neither the deferred call nor t.Cleanup waits for it", pass.Fset.PositionFor(goStmt.Go, false).Line, shared.Name())
+		related = append([]RelatedInformation{{
+			Pos:     goStmt.Go,
+			End:     goStmt.Call.End(),
+			Message: fmt.Sprintf("this goroutine uses %q, which the deferred call releases", shared.Name()),
+		}}, related...)
+	case result != "":
+		kind = KindNamedResult
+		msg = fmt.Sprintf("use t.Cleanup() instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow, but set the named result %q before returning; the deferred function assigns it, which a function registered with t.Cleanup cannot do", result)
+		// t.Cleanup runs after the function has returned, so no fix is suggested
+		tName = ""
+	case isDeferredSetup(pass, deferStmt.Call):
+		kind = KindSetup
+		msg = fmt.Sprintf("use t.Cleanup(%s) instead of deferring the teardown returned by a setup call, or better, register the teardown with t.Cleanup inside the setup function; a deferred teardown is skipped after t.Fatal/t.FailNow", types.ExprString(ast.Unparen(deferStmt.Call.Fun)))
+	case setup != nil:
+		kind = KindSetup
+		msg = fmt.Sprintf("use t.Cleanup(%s) instead of deferring %s, the teardown returned by the setup call %s, or better, register the teardown with t.Cleanup inside the setup function; a deferred teardown is skipped after t.Fatal/t.FailNow", teardown, teardown, types.ExprString(setup.Fun))
+	case isBenchmarkLoop(pass, loops):
+		kind = KindBenchmarkLoop
+		msg = "avoid defer in the b.N loop of a benchmark; every iteration defers another call, which piles up b.N calls until the benchmark function returns and distorts the measurement, so call it at the end of the iteration instead"
+		// b.Cleanup in the loop would pile up just the same, so no fix is suggested
+		tName = ""
+	case len(loops) > 0:
+		kind = KindLoop
+		msg = "use t.Cleanup() instead of defer in a loop in test functions; deferred calls accumulate until the test function returns instead of running per iteration, and are skipped after t.Fatal/t.FailNow"
+		// Since Go 1.22 every iteration declares its own loop variables
+		if v := capturedLoopVar(pass, loops, deferStmt.Call); v != "" && !goVersionAtLeast(pass, opts, loopVarGoVersion) {
+			msg += fmt.Sprintf("; the deferred function captures the loop variable %q, which before Go 1.22 is shared by all iterations", v)
+		}
+	}
+	if opts.FatalOrder && stop != nil && kind != KindParallel && kind != KindSelfParallel && kind != KindBenchmarkLoop {
+		msg += fmt.Sprintf("; %s on line %d may stop the test after the defer", types.ExprString(stop.Fun), pass.Fset.PositionFor(stop.Pos(), false).Line)
+	}
+
+	form := cleanupFormOf(pass, opts, ctx.node, facts.assigned, loops, tName, deferStmt)
+	return diagnose(pass, opts, ctx, Diagnostic{
+		Kind:         kind,
+		Pos:          deferStmt.Defer,
+		End:          deferStmt.Call.End(),
+		Message:      renderMessage(opts.Message, ctx, msg),
+		Fix:          cleanupReplacement(pass, form, tName, deferStmt),
+		SuggestedFix: suggestCleanupFix(form, tName, deferStmt),
+		Related:      related,
+	}), true
+}
+
+// assignedResult returns the name of a named result of the function fn that the deferred
+// function literal assigns, or "" if there is none
+func assignedResult(pass *analysis.Pass, fn ast.Node, call *ast.CallExpr) string {
+	lit, ok := ast.Unparen(call.Fun).(*ast.FuncLit)
+	if !ok {
+		return ""
+	}
+
+	var fnType *ast.FuncType
+	switch fn := fn.(type) {
+	case *ast.FuncDecl:
+		fnType = fn.Type
+	case *ast.FuncLit:
+		fnType = fn.Type
+	}
+	if fnType == nil || fnType.Results == nil {
+		return ""
+	}
+	results := make(map[types.Object]bool)
+	for _, field := range fnType.Results.List {
+		for _, name := range field.Names {
+			if obj := pass.TypesInfo.Defs[name]; obj != nil {
+				results[obj] = true
+			}
+		}
+	}
+	if len(results) == 0 {
+		return ""
+	}
+
+	var assigned string
+	isResult := func(expr ast.Expr) {
+		if ident, ok := ast.Unparen(expr).(*ast.Ident); ok && assigned == "" && results[pass.TypesInfo.Uses[ident]] {
+			assigned = ident.Name
+		}
+	}
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		switch stmt := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range stmt.Lhs {
+				isResult(lhs)
+			}
+		case *ast.IncDecStmt:
+			isResult(stmt.X)
+		}
+		return assigned == ""
+	})
+	return assigned
+}
+
+// isDeferredSetup checks if the deferred call calls the result of another call, as in
+// defer setupTest(t)(), where setupTest runs right away and returns the teardown.
+// Conversions such as defer cleanupFunc(fn)() only call fn, so they are not setup calls.
+func isDeferredSetup(pass *analysis.Pass, call *ast.CallExpr) bool {
+	inner, ok := ast.Unparen(call.Fun).(*ast.CallExpr)
+	return ok && !isConversion(pass, inner)
+}
+
+// isConversion checks if the call is a type conversion, such as cleanupFunc(fn) or (func())(fn)
+func isConversion(pass *analysis.Pass, call *ast.CallExpr) bool {
+	tv, ok := pass.TypesInfo.Types[call.Fun]
+	return ok && tv.IsType()
+}
+
+// boundTeardown returns the name of the variable called by the deferred call and the setup
+// call it was assigned from in the function body, as in teardown := setup(t); defer teardown(),
+// or nil if the variable is not bound to a call receiving a testing parameter there. setups
+// are the setup calls of the body, from setupCalls.
+func boundTeardown(pass *analysis.Pass, body *ast.BlockStmt, setups map[*types.Var]*ast.CallExpr, call *ast.CallExpr) (string, *ast.CallExpr) {
+	ident, ok := ast.Unparen(call.Fun).(*ast.Ident)
+	if !ok || len(call.Args) > 0 {
+		return "", nil
+	}
+	v, ok := pass.TypesInfo.Uses[ident].(*types.Var)
+	if !ok || v.Pos() < body.Pos() || v.Pos() >= body.End() {
+		return "", nil
+	}
+	setup := setups[v]
+	if setup == nil {
+		return "", nil
+	}
+	return ident.Name, setup
+}
+
+// setupCalls returns the calls receiving a testing parameter that the variables of the
+// body are bound to outside nested function literals, keeping the last one of each
+func setupCalls(pass *analysis.Pass, body *ast.BlockStmt) map[*types.Var]*ast.CallExpr {
+	setups := make(map[*types.Var]*ast.CallExpr)
+	bind := func(lhs []*ast.Ident, rhs []ast.Expr) {
+		for i, id := range lhs {
+			if id == nil {
+				continue
+			}
+			v, ok := pass.TypesInfo.ObjectOf(id).(*types.Var)
+			if !ok {
+				continue
+			}
+			var value ast.Expr
+			switch {
+			case len(rhs) == len(lhs):
+				value = rhs[i]
+			case len(rhs) == 1:
+				// The teardown is one of several results, as in db, teardown := setup(t)
+				value = rhs[0]
+			}
+			if c, ok := ast.Unparen(value).(*ast.CallExpr); ok && passesTestingParam(pass, c) {
+				setups[v] = c
+			}
+		}
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncLit:
 			return false
+		case *ast.AssignStmt:
+			var lhs []*ast.Ident
+			for _, e := range node.Lhs {
+				id, _ := e.(*ast.Ident)
+				lhs = append(lhs, id)
+			}
+			bind(lhs, node.Rhs)
+		case *ast.ValueSpec:
+			bind(node.Names, node.Values)
 		}
 		return true
 	})
+	return setups
 }
 
-// hasFuncLitTestingTParam checks if the function literal has a *testing.T parameter
-func hasFuncLitTestingTParam(funcLit *ast.FuncLit) bool {
-	if funcLit.Type == nil || funcLit.Type.Params == nil {
+// passesTestingParam checks if one of the arguments of the call is a testing parameter, as
+// for setup functions like setup(t)
+func passesTestingParam(pass *analysis.Pass, call *ast.CallExpr) bool {
+	for _, arg := range call.Args {
+		if isTestingParamType(pass.TypesInfo.TypeOf(arg)) {
+			return true
+		}
+	}
+	return false
+}
+
+// funcLitContext returns the context to check the function literal in, given the
+// enclosing contexts; a nil context and true means only literals nested in it may be
+// checked, and false that nothing inside is checked
+func funcLitContext(pass *analysis.Pass, opts *Options, node *ast.FuncLit, stack []ast.Node, contexts []*testContext) (*testContext, bool) {
+	if opts.Ginkgo {
+		// Closures of Ginkgo nodes run as specs wherever they are declared
+		if name, ok := ginkgoNode(pass, stack); ok {
+			return &testContext{
+				node:   node,
+				body:   node.Body,
+				name:   name,
+				ginkgo: true,
+			}, true
+		}
+		if len(contexts) == 0 {
+			return nil, true
+		}
+	}
+	if len(contexts) == 0 || contexts[len(contexts)-1].example {
+		return nil, false
+	}
+	outer := contexts[len(contexts)-1]
+	// Functions registered with t.Cleanup are already cleanup, so defers there are suspect too
+	if isCleanupFunc(pass, stack) {
+		return &testContext{
+			node:    node,
+			body:    node.Body,
+			name:    outer.name,
+			tName:   outer.tName,
+			cleanup: true,
+		}, true
+	}
+	// Only function literals with their own *testing.T parameter inside a test
+	// function are checked, wherever they appear, such as in tables of subtests;
+	// anything nested in other literals runs in another frame
+	tName := testingParamName(pass, node.Type.Params)
+	if !hasFuncLitTestingTParam(pass, node) {
+		// Closures taking a fixture that stores a *testing.T can stop the test just the same
+		field, ok := testingFieldParam(pass, node.Type.Params)
+		if !opts.CheckTFields || !ok {
+			return nil, false
+		}
+		tName = field
+	}
+	// t.Fatal must not be called from a goroutine, so the rationale does not apply there
+	goroutine := isGoroutine(stack)
+	if !opts.FlagGoroutineDefers && goroutine {
+		return nil, false
+	}
+	return &testContext{
+		node:      node,
+		body:      node.Body,
+		name:      outer.name,
+		tName:     tName,
+		goroutine: goroutine,
+	}, true
+}
+
+// depthDiagnostic returns the note that the function literal, nested in ctx, is
+// deeper than -max-depth and not checked
+func depthDiagnostic(pass *analysis.Pass, opts *Options, ctx *testContext, lit *ast.FuncLit) Diagnostic {
+	d := diagnose(pass, opts, ctx, Diagnostic{
+		Kind:    KindMaxDepth,
+		Pos:     lit.Pos(),
+		Message: fmt.Sprintf("function literals nested more than %d deep are not checked in test function %q", opts.MaxDepth, ctx.name),
+	})
+	d.Severity = "info"
+	return d
+}
+
+// checkCleanupFatal reports a call to t.Fatal or t.FailNow in a function registered with
+// t.Cleanup, which skips the rest of that function
+func checkCleanupFatal(pass *analysis.Pass, opts *Options, ctx *testContext, ignored map[int]bool, call *ast.CallExpr) (Diagnostic, bool) {
+	if !ctx.cleanup || ignored[pass.Fset.PositionFor(call.Pos(), false).Line] {
+		return Diagnostic{}, false
+	}
+	if !isTestingMethod(pass, call, "Fatal") && !isTestingMethod(pass, call, "Fatalf") && !isTestingMethod(pass, call, "FailNow") {
+		return Diagnostic{}, false
+	}
+
+	return diagnose(pass, opts, ctx, Diagnostic{
+		Kind:    KindCleanupFatal,
+		Pos:     call.Pos(),
+		End:     call.End(),
+		Message: fmt.Sprintf("avoid t.Fatal/t.FailNow in functions registered with t.Cleanup; it skips the rest of the cleanup function, so report with t.Error and continue the cleanup, in test function %q", ctx.name),
+	}), true
+}
+
+// stopAfter returns the first of the calls that may stop the test after the defer
+// statement, or nil if there is none. In a loop, the calls before the defer in the loop
+// also follow the defers of earlier iterations.
+func stopAfter(stops []*ast.CallExpr, deferStmt *ast.DeferStmt, loops []ast.Stmt) *ast.CallExpr {
+	pos := deferStmt.End()
+	if len(loops) > 0 {
+		pos = loops[len(loops)-1].Pos()
+	}
+	return callAfter(stops, pos)
+}
+
+// callAfter returns the first of the calls, in source order, after pos, or nil if there is none
+func callAfter(calls []*ast.CallExpr, pos token.Pos) *ast.CallExpr {
+	for _, call := range calls {
+		if call.Pos() > pos {
+			return call
+		}
+	}
+	return nil
+}
+
+// stopRelated returns the call that may stop the test after the defer, as related
+// information, or nil if there is none
+func stopRelated(call *ast.CallExpr) []RelatedInformation {
+	if call == nil {
+		return nil
+	}
+	return []RelatedInformation{{
+		Pos:     call.Pos(),
+		End:     call.End(),
+		Message: "the deferred call is skipped when this call stops the test",
+	}}
+}
+
+// checkMixedCleanup reports, as information, a test function that uses both defer and
+// t.Cleanup, whose relative order is easy to get wrong, unless an ignore directive is on
+// the line of the function
+func checkMixedCleanup(pass *analysis.Pass, opts *Options, ignored map[int]bool, ctx *testContext) (Diagnostic, bool) {
+	if ctx.example || ctx.cleanup || ctx.defers == 0 || !hasCleanupCall(pass, ctx.body) {
+		return Diagnostic{}, false
+	}
+
+	pos := ctx.node.Pos()
+	if decl, ok := ctx.node.(*ast.FuncDecl); ok {
+		pos = decl.Name.Pos()
+	}
+	if ignored[pass.Fset.PositionFor(pos, false).Line] {
+		return Diagnostic{}, false
+	}
+	d := diagnose(pass, opts, ctx, Diagnostic{
+		Kind:    KindMixed,
+		Pos:     pos,
+		Message: fmt.Sprintf("defer and t.Cleanup are mixed; deferred calls run when the function returns, before the functions registered with t.Cleanup, which run after the test and its subtests have completed, in test function %q", ctx.name),
+	})
+	d.Severity = "info"
+	return d, true
+}
+
+// ignoreBuildTag is the build tag that excludes a file when its build constraint mentions it,
+// as in //go:build !nodefertest_ignore
+const ignoreBuildTag = "nodefertest_ignore"
+
+// optInMarker is the comment that opts a file in to the check with -opt-in
+const optInMarker = "//go:nodefertest"
+
+// hasOptInMarker checks if the file has the opt-in marker comment on a line of its own
+func hasOptInMarker(f *ast.File) bool {
+	for _, group := range f.Comments {
+		for _, c := range group.List {
+			if strings.TrimSpace(c.Text) == optInMarker {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isTestFile checks if the file is a _test.go file, the only files go test runs tests from
+func isTestFile(pass *analysis.Pass, f *ast.File) bool {
+	return strings.HasSuffix(pass.Fset.File(f.Pos()).Name(), "_test.go")
+}
+
+// isDependency checks if the package of the pass is in the standard library or in a module
+// required by the analyzed ones, rather than being one of the packages under analysis
+func isDependency(pass *analysis.Pass) bool {
+	if pass.Module != nil && pass.Module.Version != "" {
+		return true
+	}
+	if len(pass.Files) == 0 || build.Default.GOROOT == "" {
 		return false
 	}
+	name := filepath.Clean(pass.Fset.File(pass.Files[0].Pos()).Name())
+	return strings.HasPrefix(name, filepath.Join(build.Default.GOROOT, "src")+string(filepath.Separator))
+}
 
-	for _, field := range funcLit.Type.Params.List {
-		starExpr, ok := field.Type.(*ast.StarExpr)
-		if !ok {
-			continue
+// isExcludedFile checks if the file is excluded by -exclude-files, by -exclude-dirs, or by a
+// build constraint mentioning the ignore build tag
+func isExcludedFile(pass *analysis.Pass, opts *Options, f *ast.File) bool {
+	if opts.ExcludeFiles != nil && opts.ExcludeFiles.MatchString(pass.Fset.File(f.Pos()).Name()) {
+		return true
+	}
+	if len(opts.ExcludeDirs) > 0 {
+		path := filepath.ToSlash(pass.Fset.File(f.Pos()).Name())
+		if slices.ContainsFunc(opts.ExcludeDirs, func(dir string) bool { return strings.Contains(path, dir) }) {
+			return true
 		}
+	}
 
-		selectorExpr, ok := starExpr.X.(*ast.SelectorExpr)
-		if !ok {
-			continue
+	// Build constraints must appear before the package clause
+	for _, group := range f.Comments {
+		if group.Pos() >= f.Package {
+			break
+		}
+		for _, c := range group.List {
+			if !constraint.IsGoBuild(c.Text) {
+				continue
+			}
+			expr, err := constraint.Parse(c.Text)
+			if err != nil {
+				continue
+			}
+			mentioned := false
+			expr.Eval(func(tag string) bool {
+				mentioned = mentioned || tag == ignoreBuildTag
+				return false
+			})
+			if mentioned {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ignoreDirective is the comment that suppresses the diagnostic for a defer
+const ignoreDirective = "//nodefertest:ignore"
+
+// ignoredLines returns the lines of the file whose defer is suppressed by an ignore directive.
+// A trailing directive covers its own line; a directive on a line of its own covers the next line.
+// With -respect-intentional-comment, a comment on the lines right above that matches
+// -intentional-comment covers the next line too.
+func ignoredLines(pass *analysis.Pass, opts *Options, f *ast.File) map[int]bool {
+	lines := make(map[int]bool)
+	var codeEnds map[int]token.Pos // line -> earliest end of code on that line
+	for _, group := range f.Comments {
+		for _, c := range group.List {
+			if !strings.HasPrefix(c.Text, ignoreDirective) {
+				continue
+			}
+
+			if codeEnds == nil {
+				codeEnds = codeEndsByLine(pass, f)
+			}
+
+			line := pass.Fset.PositionFor(c.Slash, false).Line
+			if end, ok := codeEnds[line]; ok && end <= c.Slash {
+				lines[line] = true
+			} else {
+				lines[line+1] = true
+			}
 		}
 
-		ident, ok := selectorExpr.X.(*ast.Ident)
-		if !ok {
+		if !opts.RespectIntentionalComment || opts.IntentionalComment == nil || !opts.IntentionalComment.MatchString(group.Text()) {
 			continue
 		}
+		if codeEnds == nil {
+			codeEnds = codeEndsByLine(pass, f)
+		}
+		// Trailing comments explain the code before them, not the next line
+		if end, ok := codeEnds[pass.Fset.PositionFor(group.Pos(), false).Line]; ok && end <= group.Pos() {
+			continue
+		}
+		lines[pass.Fset.PositionFor(group.End(), false).Line+1] = true
+	}
+	return lines
+}
 
-		// Check if it's testing.T or testing.B
-		if ident.Name == "testing" && (selectorExpr.Sel.Name == "T" || selectorExpr.Sel.Name == "B") {
+// codeEndsByLine records, for each line, the earliest position at which a node ends on it
+func codeEndsByLine(pass *analysis.Pass, f *ast.File) map[int]token.Pos {
+	ends := make(map[int]token.Pos)
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil, *ast.File, *ast.Comment, *ast.CommentGroup:
+			return true
+		}
+		line := pass.Fset.PositionFor(n.End(), false).Line
+		if end, ok := ends[line]; !ok || n.End() < end {
+			ends[line] = n.End()
+		}
+		return true
+	})
+	return ends
+}
+
+// hasFuncLitTestingTParam checks if the function literal has a *testing.T parameter
+func hasFuncLitTestingTParam(pass *analysis.Pass, funcLit *ast.FuncLit) bool {
+	if funcLit.Type == nil || funcLit.Type.Params == nil {
+		return false
+	}
+
+	for _, field := range funcLit.Type.Params.List {
+		if isTestingParamField(pass, field) {
 			return true
 		}
 	}
@@ -90,46 +1439,248 @@ func hasFuncLitTestingTParam(funcLit *ast.FuncLit) bool {
 	return false
 }
 
+// funcKind is a kind of function run by go test
+type funcKind int
+
+const (
+	// notTestFunc is any other function
+	notTestFunc funcKind = iota
+	// testFunc is a test, benchmark, or fuzz function, which takes a testing parameter
+	testFunc
+	// exampleFunc is an example function, which takes no parameters
+	exampleFunc
+)
+
+// testFuncKind returns the kind of the function by its name, and for examples
+// its signature; examples are only checked with -check-examples
+func testFuncKind(opts *Options, funcDecl *ast.FuncDecl) funcKind {
+	if isExampleFunction(funcDecl) {
+		if opts.CheckExamples {
+			return exampleFunc
+		}
+		return notTestFunc
+	}
+	if isTestFunction(opts, funcDecl) {
+		return testFunc
+	}
+	return notTestFunc
+}
+
+// notTestReason returns why the function is not a test function by its name, for -explain
+func notTestReason(opts *Options, funcDecl *ast.FuncDecl) string {
+	name := funcDecl.Name.Name
+	switch {
+	case name == "TestMain":
+		return "TestMain sets up the test binary rather than being a test"
+	case isExampleFunction(funcDecl):
+		return "an example function, which is only checked with -check-examples"
+	case hasTestPrefix(name, "Benchmark") && !opts.CheckBenchmarks:
+		return "a benchmark, with -check-benchmarks=false"
+	}
+	return fmt.Sprintf("the name does not start with one of the -prefixes %s followed by a non-lowercase letter", strings.Join(opts.Prefixes, ", "))
+}
+
 // isTestFunction checks if the function is a test function
-func isTestFunction(funcDecl *ast.FuncDecl) bool {
+func isTestFunction(opts *Options, funcDecl *ast.FuncDecl) bool {
 	name := funcDecl.Name.Name
-	// Test functions start with "Test", "Benchmark", or "Example"
-	if len(name) > 4 && name[:4] == "Test" {
-		return true
+	// TestMain sets up the test binary rather than being a test; m.Run has no Cleanup
+	// and defers there run normally unless os.Exit is called
+	if name == "TestMain" {
+		return false
 	}
-	if len(name) > 9 && name[:9] == "Benchmark" {
-		return true
+	if hasTestPrefix(name, "Benchmark") && !opts.CheckBenchmarks {
+		return false
+	}
+	// Test functions start with one of the prefixes, "Test", "Benchmark", or "Fuzz" by default
+	for _, prefix := range opts.Prefixes {
+		if hasTestPrefix(name, prefix) {
+			return true
+		}
 	}
 	return false
 }
 
+// isExampleFunction checks if the function is an example function.
+// Unlike tests, a bare "Example" is valid as the package example.
+func isExampleFunction(funcDecl *ast.FuncDecl) bool {
+	if funcDecl.Recv != nil || funcDecl.Type.Params.NumFields() > 0 {
+		return false
+	}
+	name := funcDecl.Name.Name
+	return name == "Example" || hasTestPrefix(name, "Example")
+}
+
+// outputPrefix matches the output comment of a runnable example, as go test recognizes it
+var outputPrefix = regexp.MustCompile(`(?i)^[[:space:]]*(unordered )?output:`)
+
+// hasOutputComment checks if the example body ends with an // Output: comment, which
+// makes it a runnable example; like go test, only the last comment of the body counts
+func hasOutputComment(file *ast.File, body *ast.BlockStmt) bool {
+	var last *ast.CommentGroup
+	for _, group := range file.Comments {
+		if group.Pos() > body.Lbrace && group.End() < body.Rbrace {
+			last = group
+		}
+	}
+	return last != nil && outputPrefix.MatchString(last.Text())
+}
+
+// hasTestPrefix checks if name is prefix followed by a non-lowercase rune, so "Testify"
+// is not a test but "TestA" and "Test_a" are. Unlike go test, which runs them, the bare
+// names Test, Benchmark, and Fuzz are intentionally not treated as tests.
+func hasTestPrefix(name, prefix string) bool {
+	if len(name) <= len(prefix) || !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+	return !unicode.IsLower(r)
+}
+
 // hasTestingTParam checks if the function has a *testing.T parameter
-func hasTestingTParam(funcDecl *ast.FuncDecl) bool {
+func hasTestingTParam(pass *analysis.Pass, funcDecl *ast.FuncDecl) bool {
 	if funcDecl.Type.Params == nil || len(funcDecl.Type.Params.List) == 0 {
 		return false
 	}
 
 	for _, field := range funcDecl.Type.Params.List {
-		starExpr, ok := field.Type.(*ast.StarExpr)
-		if !ok {
-			continue
+		if isTestingParamField(pass, field) {
+			return true
 		}
+	}
 
-		selectorExpr, ok := starExpr.X.(*ast.SelectorExpr)
-		if !ok {
+	return false
+}
+
+// hasOnlyTBParams checks if every testing parameter of the function is a testing.TB,
+// as in generic utilities shared by tests and benchmarks
+func hasOnlyTBParams(pass *analysis.Pass, funcDecl *ast.FuncDecl) bool {
+	if funcDecl.Type.Params == nil {
+		return false
+	}
+
+	found := false
+	for _, field := range funcDecl.Type.Params.List {
+		if !isTestingParamField(pass, field) {
 			continue
 		}
+		if !isTestingNamed(types.Unalias(pass.TypesInfo.TypeOf(field.Type)), "TB") {
+			return false
+		}
+		found = true
+	}
+	return found
+}
 
-		ident, ok := selectorExpr.X.(*ast.Ident)
-		if !ok {
+// hasTestSignature checks if the function has exactly the signature of a test run by
+// go test, a single *testing.T, *testing.B, *testing.F, or testing.TB parameter and no results
+func hasTestSignature(pass *analysis.Pass, funcDecl *ast.FuncDecl) bool {
+	if funcDecl.Type.Params == nil {
+		return false
+	}
+	params := funcDecl.Type.Params.List
+	if len(params) != 1 || len(params[0].Names) > 1 || funcDecl.Type.Results != nil {
+		return false
+	}
+	return isTestingParamField(pass, params[0])
+}
+
+// isSuiteMethod checks if the function is a method on a type embedding testify's suite.Suite,
+// whose test methods reach *testing.T through s.T() instead of a parameter. Value and
+// pointer receivers are treated alike, as are suites embedded by value or by pointer.
+func isSuiteMethod(pass *analysis.Pass, funcDecl *ast.FuncDecl) bool {
+	if funcDecl.Recv == nil || len(funcDecl.Recv.List) == 0 {
+		return false
+	}
+
+	recvType := pass.TypesInfo.TypeOf(funcDecl.Recv.List[0].Type)
+	if recvType == nil {
+		return false
+	}
+
+	obj, _, _ := types.LookupFieldOrMethod(recvType, true, nil, "Suite")
+	field, ok := obj.(*types.Var)
+	if !ok || !field.Embedded() {
+		return false
+	}
+
+	typ := types.Unalias(field.Type())
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = types.Unalias(ptr.Elem())
+	}
+
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return false
+	}
+
+	return named.Obj().Pkg() != nil && trimVendor(named.Obj().Pkg().Path()) == "github.com/stretchr/testify/suite"
+}
+
+// trimVendor removes the vendor directory prefix from a package path
+func trimVendor(path string) string {
+	if i := strings.LastIndex(path, "/vendor/"); i >= 0 {
+		return path[i+len("/vendor/"):]
+	}
+	return strings.TrimPrefix(path, "vendor/")
+}
+
+// testingParamName returns the name of the first *testing.T, *testing.B, *testing.F, or
+// testing.TB parameter that is not blank, or "" if there is none, so that functions with
+// several use the same one for messages and fixes; ...*testing.T is a slice and never matches
+func testingParamName(pass *analysis.Pass, params *ast.FieldList) string {
+	if params == nil {
+		return ""
+	}
+
+	for _, field := range params.List {
+		if !isTestingParamField(pass, field) {
 			continue
 		}
-
-		// Check if it's testing.T or testing.B
-		if ident.Name == "testing" && (selectorExpr.Sel.Name == "T" || selectorExpr.Sel.Name == "B") {
-			return true
+		for _, name := range field.Names {
+			if name.Name != "_" {
+				return name.Name
+			}
 		}
 	}
 
-	return false
+	return ""
+}
+
+// isTestingParamField checks if the parameter has a testing type, allowing for the nil
+// fields and types that the parser leaves in partially parsed code
+func isTestingParamField(pass *analysis.Pass, field *ast.Field) bool {
+	return field != nil && field.Type != nil && isTestingParamType(pass.TypesInfo.TypeOf(field.Type))
+}
+
+// isTestingParamType checks if the type is *testing.T, *testing.B, *testing.F, or testing.TB,
+// resolved through the type checker so aliases and dot imports are handled
+func isTestingParamType(typ types.Type) bool {
+	typ = types.Unalias(typ)
+
+	// testing.TB is an interface, so it is not behind a pointer
+	if isTestingNamed(typ, "TB") {
+		return true
+	}
+
+	ptr, ok := typ.(*types.Pointer)
+	if !ok {
+		return false
+	}
+
+	return isTestingNamed(types.Unalias(ptr.Elem()), "T", "B", "F")
+}
+
+// isTestingNamed checks if the type is one of the named types of the testing package
+func isTestingNamed(typ types.Type, names ...string) bool {
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return false
+	}
+
+	obj := named.Obj()
+	if obj.Pkg() == nil || obj.Pkg().Path() != "testing" {
+		return false
+	}
+
+	return slices.Contains(names, obj.Name())
 }
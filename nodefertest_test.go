@@ -1,15 +1,1331 @@
 package nodefertest_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/gostaticanalysis/testutil"
 	"github.com/s4s7/nodefertest"
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/analysistest"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
 )
 
+// TestMain checks testdata files whatever their names, since most of the testdata
+// packages are plain .go files rather than _test.go files; TestOnlyTestFiles covers
+// the default -only-test-files.
+func TestMain(m *testing.M) {
+	if err := nodefertest.Analyzer.Flags.Set("only-test-files", "false"); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
 // TestAnalyzer is a test for Analyzer.
 func TestAnalyzer(t *testing.T) {
 	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
-	analysistest.Run(t, testdata, nodefertest.Analyzer, "a")
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "a", "a/localt")
+}
+
+// TestSuggestedFixes is a test for the fixes suggested by Analyzer.
+// testutil.WithModules is not used here since it prepends a //line directive
+// to each copied file, which would then have to appear in the golden files.
+func TestSuggestedFixes(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), nodefertest.Analyzer, "fix")
+}
+
+// TestSkipBenchmarks is a test for Analyzer with -check-benchmarks=false.
+func TestSkipBenchmarks(t *testing.T) {
+	setFlag(t, "check-benchmarks", "false")
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "nobench")
+}
+
+// setFlag sets an Analyzer flag for the duration of the test.
+func setFlag(t *testing.T, name, value string) {
+	t.Helper()
+	f := nodefertest.Analyzer.Flags.Lookup(name)
+	old := f.Value.String()
+	if err := f.Value.Set(value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := f.Value.Set(old); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// TestSuite is a test for Analyzer on testify suite methods.
+// testdata/src/testify is a stub of github.com/stretchr/testify.
+func TestSuite(t *testing.T) {
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "suitetest")
+}
+
+// TestGinkgo is a test for Analyzer with -ginkgo.
+// testdata/src/ginkgo is a stub of github.com/onsi/ginkgo/v2.
+func TestGinkgo(t *testing.T) {
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+
+	// Ginkgo nodes have no testing parameter, so nothing is checked by default
+	results := analysistest.Run(discardErrors{}, testdata, nodefertest.Analyzer, "ginkgotest")
+	if n := len(results[0].Diagnostics); n != 0 {
+		t.Errorf("got %d diagnostics without -ginkgo, want 0", n)
+	}
+
+	setFlag(t, "ginkgo", "true")
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "ginkgotest")
+}
+
+// TestErrgroup is a test for Analyzer on functions passed to errgroup.Group.Go.
+// testdata/src/xsync is a stub of golang.org/x/sync.
+func TestErrgroup(t *testing.T) {
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "errgrouptest")
+
+	// The defer of TestGroupGoFatal is not reported, since the t.Fatal calls run in goroutines
+	t.Run("require-fatal", func(t *testing.T) {
+		setFlag(t, "require-fatal", "true")
+		results := analysistest.Run(discardErrors{}, testdata, nodefertest.Analyzer, "errgrouptest")
+		var names []string
+		for _, d := range results[0].Result.([]nodefertest.Diagnostic) {
+			names = append(names, d.TestName)
+		}
+		if !slices.Equal(names, []string{"TestGroupGoAndFatal"}) {
+			t.Errorf("got diagnostics in %v, want only in TestGroupGoAndFatal", names)
+		}
+	})
+}
+
+// discardErrors is an analysistest.Testing that ignores the mismatches with want comments,
+// to count the diagnostics of a package whose want comments are for other flags
+type discardErrors struct{}
+
+func (discardErrors) Errorf(string, ...any) {}
+
+// TestDisallowUnlock is a test for Analyzer with -allow-unlock=false.
+func TestDisallowUnlock(t *testing.T) {
+	setFlag(t, "allow-unlock", "false")
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "unlock")
+}
+
+// TestRequireFatal is a test for Analyzer with -require-fatal.
+func TestRequireFatal(t *testing.T) {
+	setFlag(t, "require-fatal", "true")
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "requirefatal")
+}
+
+// TestFatalOrder is a test for Analyzer with -fatal-order.
+func TestFatalOrder(t *testing.T) {
+	setFlag(t, "fatal-order", "true")
+	analysistest.Run(t, analysistest.TestData(), nodefertest.Analyzer, "fatalorder")
+}
+
+// TestCheckExamples is a test for Analyzer with -check-examples.
+func TestCheckExamples(t *testing.T) {
+	setFlag(t, "check-examples", "true")
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "examples")
+
+	t.Run("skip-output-examples", func(t *testing.T) {
+		setFlag(t, "skip-output-examples", "true")
+		analysistest.Run(t, analysistest.TestData(), nodefertest.Analyzer, "outputexamples")
+	})
+
+	// Examples take no testing parameter even if their prefix is listed with the tests'
+	t.Run("prefixes", func(t *testing.T) {
+		setFlag(t, "prefixes", "Test,Example")
+		analysistest.Run(t, testdata, nodefertest.Analyzer, "examples")
+	})
+}
+
+// TestCheckHelpers is a test for Analyzer with -check-helpers.
+func TestCheckHelpers(t *testing.T) {
+	setFlag(t, "check-helpers", "true")
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "helpers")
+}
+
+// TestIgnoreTBHelpers is a test for Analyzer with -check-helpers and -ignore-tb-helpers.
+func TestIgnoreTBHelpers(t *testing.T) {
+	setFlag(t, "check-helpers", "true")
+	setFlag(t, "ignore-tb-helpers", "true")
+	analysistest.Run(t, analysistest.TestData(), nodefertest.Analyzer, "tbhelpers")
+}
+
+// TestRepeatedRuns is a test that Analyzer keeps no state between runs, as unitchecker
+// and nogo require, by analyzing packages with facts twice and comparing the results.
+func TestRepeatedRuns(t *testing.T) {
+	cfg := &packages.Config{
+		Mode:  packages.LoadAllSyntax,
+		Dir:   filepath.Join(analysistest.TestData(), "src", "helperfacts"),
+		Tests: true,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyze := func() []string {
+		graph, err := checker.Analyze([]*analysis.Analyzer{nodefertest.Analyzer}, pkgs, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var diags []string
+		for act := range graph.All() {
+			if act.Analyzer != nodefertest.Analyzer {
+				continue
+			}
+			if act.Err != nil {
+				t.Fatal(act.Err)
+			}
+			for _, d := range act.Diagnostics {
+				diags = append(diags, fmt.Sprintf("%v: %s", act.Package.Fset.Position(d.Pos), d.Message))
+			}
+		}
+		slices.Sort(diags)
+		return diags
+	}
+
+	first := analyze()
+	if len(first) == 0 {
+		t.Fatal("got no diagnostics")
+	}
+	if second := analyze(); !slices.Equal(first, second) {
+		t.Errorf("second run differs:\n%s\nwant:\n%s", strings.Join(second, "\n"), strings.Join(first, "\n"))
+	}
+}
+
+// BenchmarkAnalyzer measures Analyzer alone over the testdata package,
+// which is loaded once so that only the single traversal per run is timed.
+func BenchmarkAnalyzer(b *testing.B) {
+	cfg := &packages.Config{
+		Mode:  packages.LoadAllSyntax,
+		Dir:   filepath.Join(analysistest.TestData(), "src", "a"),
+		Tests: true,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for b.Loop() {
+		if _, err := checker.Analyze([]*analysis.Analyzer{nodefertest.Analyzer}, pkgs, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkAnalyzerLarge measures Analyzer over a generated package of hundreds of
+// test functions with nested subtests, as a baseline for the cost of the traversal.
+func BenchmarkAnalyzerLarge(b *testing.B) {
+	const tests = 500
+	var src strings.Builder
+	src.WriteString("package large\n\nimport \"testing\"\n\nfunc cleanup() {}\n")
+	for i := range tests {
+		fmt.Fprintf(&src, `
+func Test%d(t *testing.T) {
+	defer cleanup()
+	for i := range 3 {
+		t.Run("sub", func(t *testing.T) {
+			defer func() { cleanup() }()
+			t.Run("nested", func(t *testing.T) {
+				defer t.Log(i)
+				if i > 1 {
+					t.Fatal("stop")
+				}
+			})
+		})
+	}
+	t.Cleanup(cleanup)
+}
+`, i)
+	}
+
+	dir := b.TempDir()
+	files := map[string]string{
+		"go.mod":        "module large\n\ngo 1.25.1\n",
+		"large_test.go": src.String(),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	cfg := &packages.Config{
+		Mode:  packages.LoadAllSyntax,
+		Dir:   dir,
+		Tests: true,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		b.Fatal(err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		b.Fatal("generated package has errors")
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := checker.Analyze([]*analysis.Analyzer{nodefertest.Analyzer}, pkgs, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestRelatedInformation is a test for the related information attached to diagnostics.
+func TestRelatedInformation(t *testing.T) {
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	results := analysistest.Run(t, testdata, nodefertest.Analyzer, "related")
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+
+	// Diagnostics are in source order: cleanup, func literal, os.Remove
+	want := []string{
+		"replace with t.Cleanup(cleanup)",
+		"replace with t.Cleanup(func() {...})",
+		`replace with t.Cleanup(func() { os.Remove("file") })`,
+	}
+	diags := results[0].Diagnostics
+	if len(diags) != len(want) {
+		t.Fatalf("got %d diagnostics, want %d", len(diags), len(want))
+	}
+	for i, d := range diags {
+		var got string
+		if len(d.Related) > 0 {
+			got = d.Related[0].Message
+		}
+		if got != want[i] {
+			t.Errorf("diagnostic %d: got related %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+// TestStopRelated is a test for the call that stops the test after a defer, attached
+// to its diagnostic as related information.
+func TestStopRelated(t *testing.T) {
+	results := analysistest.Run(t, analysistest.TestData(), nodefertest.Analyzer, "stoprelated")
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+
+	const message = "the deferred call is skipped when this call stops the test"
+	fset := results[0].Action.Package.Fset
+	var lines []int
+	for _, d := range results[0].Diagnostics {
+		if !strings.HasPrefix(d.Message, "use t.Cleanup() instead of defer") {
+			continue
+		}
+		line := 0
+		for _, r := range d.Related {
+			if r.Message == message {
+				line = fset.Position(r.Pos).Line
+			}
+		}
+		lines = append(lines, line)
+	}
+	// The t.Fatal in TestStopRelated on line 14, past the one before the defer and the one in
+	// the subtest; nothing for TestNoStop, nor for TestStopAfterDefers, whose t.Fatal calls
+	// only run after the deferred calls
+	if want := []int{14, 0, 0, 0}; !slices.Equal(lines, want) {
+		t.Errorf("got related lines %v, want %v", lines, want)
+	}
+}
+
+// TestPrefixes is a test for Analyzer with custom -prefixes.
+func TestPrefixes(t *testing.T) {
+	setFlag(t, "prefixes", "IntegrationTest,AcceptanceTest")
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "prefixes")
+}
+
+// TestSeverity is a test for the severity set from -severity.
+func TestSeverity(t *testing.T) {
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	for _, severity := range []string{"error", "warning", "info"} {
+		t.Run(severity, func(t *testing.T) {
+			setFlag(t, "severity", severity)
+			results := analysistest.Run(t, testdata, nodefertest.Analyzer, "severity")
+			for _, r := range results {
+				for _, d := range r.Result.([]nodefertest.Diagnostic) {
+					if d.Severity != severity {
+						t.Errorf("got severity %q, want %q", d.Severity, severity)
+					}
+				}
+				// Drivers only see the category of the reported diagnostics
+				for _, d := range r.Diagnostics {
+					if want := severity + "/defer-generic"; d.Category != want {
+						t.Errorf("got category %q, want %q", d.Category, want)
+					}
+				}
+			}
+		})
+	}
+
+	if err := nodefertest.Analyzer.Flags.Set("severity", "fatal"); err == nil {
+		t.Error("expected an error for an invalid severity")
+	}
+}
+
+// TestCategories is a test for the category of each kind of diagnostic, given in the
+// comment above it in the testdata.
+func TestCategories(t *testing.T) {
+	setFlag(t, "flag-goroutine-defers", "true")
+	results := analysistest.Run(t, analysistest.TestData(), nodefertest.Analyzer, "categories")
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+
+	fset := results[0].Action.Package.Fset
+	diags := results[0].Diagnostics
+	if len(diags) == 0 {
+		t.Fatal("got no diagnostics")
+	}
+	for _, d := range diags {
+		pos := fset.Position(d.Pos)
+		src, err := os.ReadFile(pos.Filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(string(src), "\n")
+		want, ok := strings.CutPrefix(strings.TrimSpace(lines[pos.Line-2]), "// category: ")
+		if !ok {
+			t.Errorf("%v: no category comment above the diagnostic", pos)
+			continue
+		}
+		// The category starts with the severity, such as error/ or info/
+		if _, got, _ := strings.Cut(d.Category, "/"); got != want {
+			t.Errorf("%v: got category %q, want %q after the severity", pos, d.Category, want)
+		}
+	}
+}
+
+// TestURL is a test for the documentation URLs of Analyzer and its diagnostics.
+func TestURL(t *testing.T) {
+	if nodefertest.Analyzer.URL == "" {
+		t.Fatal("Analyzer.URL is empty")
+	}
+
+	setFlag(t, "flag-goroutine-defers", "true")
+	results := analysistest.Run(t, analysistest.TestData(), nodefertest.Analyzer, "categories")
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	if len(results[0].Diagnostics) == 0 {
+		t.Fatal("got no diagnostics")
+	}
+	for _, d := range results[0].Diagnostics {
+		_, category, _ := strings.Cut(d.Category, "/")
+		if want := nodefertest.Analyzer.URL + "#" + category; d.URL != want {
+			t.Errorf("%v: got URL %q, want %q", results[0].Action.Package.Fset.Position(d.Pos), d.URL, want)
+		}
+	}
+	for _, d := range results[0].Result.([]nodefertest.Diagnostic) {
+		if !strings.HasPrefix(d.URL, nodefertest.Analyzer.URL+"#") {
+			t.Errorf("%s:%d: got URL %q in the result", d.File, d.Line, d.URL)
+		}
+	}
+}
+
+// TestSharedWithGoroutine is a test for Analyzer on defers releasing what goroutines use.
+func TestSharedWithGoroutine(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), nodefertest.Analyzer, "sharedgo")
+}
+
+// TestFlagGoroutineDefers is a test for Analyzer with -flag-goroutine-defers.
+func TestFlagGoroutineDefers(t *testing.T) {
+	setFlag(t, "flag-goroutine-defers", "true")
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "goroutine")
+}
+
+// TestFormatDiagnostics is a test for FormatDiagnostics on the result of Analyzer.
+func TestFormatDiagnostics(t *testing.T) {
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	results := analysistest.Run(t, testdata, nodefertest.Analyzer, "related")
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+
+	diags, ok := results[0].Result.([]nodefertest.Diagnostic)
+	if !ok {
+		t.Fatalf("got result of type %T, want []nodefertest.Diagnostic", results[0].Result)
+	}
+
+	var buf bytes.Buffer
+	if err := nodefertest.FormatDiagnostics(&buf, diags); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d diagnostics, want 3\n%s", len(got), buf.String())
+	}
+
+	for i, d := range got {
+		for _, key := range []string{"file", "line", "column", "test_name", "kind", "message", "severity", "url"} {
+			if _, ok := d[key]; !ok {
+				t.Errorf("diagnostic %d: missing key %q", i, key)
+			}
+		}
+		if d["test_name"] != "TestRelated" {
+			t.Errorf("diagnostic %d: got test_name %v, want TestRelated", i, d["test_name"])
+		}
+	}
+
+	// os.Remove("file") has arguments, so it is wrapped in a closure
+	if got[0]["fix"] != "t.Cleanup(cleanup)" {
+		t.Errorf("got fix %v, want t.Cleanup(cleanup)", got[0]["fix"])
+	}
+	if want := `t.Cleanup(func() { os.Remove("file") })`; got[2]["fix"] != want {
+		t.Errorf("got fix %v, want %s", got[2]["fix"], want)
+	}
+}
+
+// TestCheckTFields is a test for Analyzer with -check-t-fields.
+func TestCheckTFields(t *testing.T) {
+	setFlag(t, "check-t-fields", "true")
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	results := analysistest.Run(t, testdata, nodefertest.Analyzer, "tfields")
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+
+	// Embedded fields are reached through their type name
+	fixes := make(map[string]bool)
+	for _, d := range results[0].Result.([]nodefertest.Diagnostic) {
+		fixes[d.Fix] = true
+	}
+	for _, want := range []string{"f.T.Cleanup(cleanup)", "f.TB.Cleanup(cleanup)", "f.embeddedFixture.T.Cleanup(cleanup)", "x.T.Cleanup(cleanup)"} {
+		if !fixes[want] {
+			t.Errorf("no fix %s, got %v", want, slices.Sorted(maps.Keys(fixes)))
+		}
+	}
+}
+
+// TestAllowCalls is a test for Analyzer with -allow-calls.
+func TestAllowCalls(t *testing.T) {
+	setFlag(t, "allow-calls", "os.Remove,os.RemoveAll,(*os.File).Close,s.Shutdown")
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "allowcalls")
+}
+
+// TestAllowPackages is a test for Analyzer with -allow-packages.
+func TestAllowPackages(t *testing.T) {
+	setFlag(t, "allow-packages", "allowpackages/testhelpers")
+	analysistest.Run(t, analysistest.TestData(), nodefertest.Analyzer, "allowpackages")
+}
+
+// TestConfig is a test for Analyzer with settings from a -config file.
+func TestConfig(t *testing.T) {
+	const config = `# Settings for nodefertest
+exclude-functions: [TestLegacy, "TestFixture"]
+allow-calls:
+  - os.RemoveAll # idempotent
+  - (*os.File).Close
+require-fatal: false
+`
+	path := filepath.Join(t.TempDir(), ".nodefertest.yaml")
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restore the flags set from the file
+	setFlag(t, "exclude-functions", "")
+	setFlag(t, "allow-calls", "")
+	setFlag(t, "require-fatal", "false")
+	// As if only -config were given on the command line
+	t.Cleanup(nodefertest.ForgetSetFlags())
+	setFlag(t, "config", path)
+	analysistest.Run(t, analysistest.TestData(), nodefertest.Analyzer, "config")
+
+	t.Run("precedence", func(t *testing.T) {
+		setFlag(t, "exclude-functions", "TestOther")
+		setFlag(t, "config", path)
+		if got := nodefertest.Analyzer.Flags.Lookup("exclude-functions").Value.String(); got != "TestOther" {
+			t.Errorf("got exclude-functions %q, want the flag value TestOther", got)
+		}
+	})
+
+	// A flag set to its default value is set all the same
+	t.Run("precedence of defaults", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".nodefertest.yaml")
+		if err := os.WriteFile(path, []byte("check-benchmarks: false\nmax-depth: 3\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// Restore the flag set from the file
+		setFlag(t, "max-depth", "1000")
+		t.Cleanup(nodefertest.ForgetSetFlags())
+		setFlag(t, "check-benchmarks", "true")
+		setFlag(t, "config", path)
+		if got := nodefertest.Analyzer.Flags.Lookup("check-benchmarks").Value.String(); got != "true" {
+			t.Errorf("got check-benchmarks %q, want the flag value true", got)
+		}
+		// Unset flags still take the settings of the file
+		if got := nodefertest.Analyzer.Flags.Lookup("max-depth").Value.String(); got != "3" {
+			t.Errorf("got max-depth %q, want the setting 3", got)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, config := range []string{
+			"unknown-setting: true\n",
+			"check-helpers: maybe\n",
+			"- os.RemoveAll\n",
+			"allow-calls: [os.RemoveAll\n",
+		} {
+			path := filepath.Join(t.TempDir(), ".nodefertest.yaml")
+			if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := nodefertest.Analyzer.Flags.Set("config", path); err == nil {
+				t.Errorf("%q: got no error", config)
+			}
+		}
+	})
+}
+
+// TestAllowSpanEnd is a test for Analyzer with -allow-span-end.
+func TestAllowSpanEnd(t *testing.T) {
+	setFlag(t, "allow-span-end", "true")
+	analysistest.Run(t, analysistest.TestData(), nodefertest.Analyzer, "spans")
+}
+
+// TestPrintFixes is a test for Analyzer with -print-fixes.
+func TestPrintFixes(t *testing.T) {
+	setFlag(t, "print-fixes", "true")
+	var buf bytes.Buffer
+	t.Cleanup(nodefertest.SetFixOutput(&buf))
+
+	results := analysistest.Run(t, analysistest.TestData(), nodefertest.Analyzer, "printfixes")
+	for _, r := range results {
+		for _, d := range r.Diagnostics {
+			if len(d.SuggestedFixes) > 0 {
+				t.Errorf("%s: got suggested fixes, want them printed instead", d.Message)
+			}
+		}
+	}
+
+	file := filepath.Join(analysistest.TestData(), "src", "printfixes", "printfixes.go")
+	want := file + ":\n\t8:2: defer cleanup()\n\t  => t.Cleanup(cleanup)\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got output\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestHelperFacts is a test for the facts exported for helpers that defer.
+func TestHelperFacts(t *testing.T) {
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "helperfacts/...")
+}
+
+// TestExcludeFiles is a test for Analyzer with -exclude-files.
+func TestExcludeFiles(t *testing.T) {
+	setFlag(t, "exclude-files", `_generated\.go$`)
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "exclude")
+}
+
+// TestExcludeDirs is a test for Analyzer with -exclude-dirs.
+func TestExcludeDirs(t *testing.T) {
+	setFlag(t, "exclude-dirs", "vendor/,third_party/")
+	analysistest.Run(t, analysistest.TestData(), nodefertest.Analyzer, "excludedirs/...")
+}
+
+// TestExplain is a test for -explain explaining why each function is checked or skipped.
+func TestExplain(t *testing.T) {
+	setFlag(t, "explain", "true")
+	setFlag(t, "exclude-functions", "TestExcluded")
+	var buf bytes.Buffer
+	t.Cleanup(nodefertest.SetExplainOutput(&buf))
+	analysistest.Run(t, analysistest.TestData(), nodefertest.Analyzer, "explain/...")
+
+	for _, want := range []string{
+		"explain_test.go:5:6: TestChecked: checked: a test function\n",
+		"explain_test.go:9:6: helper: skipped: the name does not start with one of the -prefixes Test, Benchmark, Fuzz followed by a non-lowercase letter; it takes a testing parameter, but helpers are only checked with -check-helpers\n",
+		"explain_test.go:13:6: TestExcluded: skipped: listed in -exclude-functions\n",
+		"explain_test.go:17:6: TestMain: skipped: TestMain sets up the test binary rather than being a test\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("explain output does not contain %q:\n%s", want, buf.String())
+		}
+	}
+
+	// Packages without tests are explained too, since their functions are checked
+	// without -only-test-files, which TestMain turns off
+	want := "lib.go:6:6: Open: skipped: the name does not start with one of the -prefixes"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("explain output does not contain %q:\n%s", want, buf.String())
+	}
+	// Dependencies analyzed for their facts, such as the testing package, are left out
+	if strings.Contains(buf.String(), "testing.go") {
+		t.Errorf("explain output contains a dependency:\n%s", buf.String())
+	}
+
+	// With -only-test-files, the functions of other files are never checked
+	setFlag(t, "only-test-files", "true")
+	buf.Reset()
+	analysistest.Run(discardErrors{}, analysistest.TestData(), nodefertest.Analyzer, "explain/...")
+	if strings.Contains(buf.String(), "lib.go") {
+		t.Errorf("explain output contains a file other than _test.go with -only-test-files:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "TestChecked: checked") {
+		t.Errorf("explain output does not explain TestChecked with -only-test-files:\n%s", buf.String())
+	}
+}
+
+// TestCheckFile is a test for CheckFile on a file type-checked outside of the analysis framework.
+func TestCheckFile(t *testing.T) {
+	const src = `package p
+
+import "testing"
+
+func TestA(t *testing.T) {
+	defer func() {}()
+	for range 2 {
+		defer t.Log("done")
+	}
+}
+
+func helper(t *testing.T) {
+	defer func() {}()
+	t.Fatal()
+}
+
+func TestB(t *testing.T) {
+	helper(t)
+}
+
+func notATest() {
+	defer func() {}()
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p_test.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("p", fset, []*ast.File{file}, info); err != nil {
+		t.Fatal(err)
+	}
+
+	diags := nodefertest.CheckFile(fset, file, info)
+
+	want := []struct {
+		line, column int
+		testName     string
+		message      string
+	}{
+		{6, 2, "TestA", `use t.Cleanup() instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function "TestA"`},
+		{8, 3, "TestA", `use t.Cleanup() instead of defer in a loop in test functions; deferred calls accumulate until the test function returns instead of running per iteration, and are skipped after t.Fatal/t.FailNow in test function "TestA"`},
+		{18, 2, "TestB", `helper "helper" defers calls that are skipped if it stops the test early; use t.Cleanup() in the helper in test function "TestB"`},
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("got %d diagnostics, want %d: %+v", len(diags), len(want), diags)
+	}
+	for i, w := range want {
+		d := diags[i]
+		if d.File != "p_test.go" || d.Line != w.line || d.Column != w.column {
+			t.Errorf("diagnostic %d: got position %s:%d:%d, want p_test.go:%d:%d", i, d.File, d.Line, d.Column, w.line, w.column)
+		}
+		if d.TestName != w.testName {
+			t.Errorf("diagnostic %d: got test name %q, want %q", i, d.TestName, w.testName)
+		}
+		if d.Message != w.message {
+			t.Errorf("diagnostic %d: got message %q, want %q", i, d.Message, w.message)
+		}
+	}
+}
+
+// TestCheckFileWithOptions is a test for CheckFileWithOptions, which ignores the flags.
+func TestCheckFileWithOptions(t *testing.T) {
+	const src = `package p
+
+import "testing"
+
+func TestA(t *testing.T) {
+	defer func() {}()
+}
+
+func CheckB(t *testing.T) {
+	defer func() {}()
+	t.Fatal()
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p_test.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("p", fset, []*ast.File{file}, info); err != nil {
+		t.Fatal(err)
+	}
+
+	setFlag(t, "severity", "info")
+	opts := nodefertest.DefaultOptions()
+	opts.Prefixes = []string{"Check"}
+	opts.Severity = "warning"
+	diags, err := nodefertest.CheckFileWithOptions(fset, file, info, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 {
+		t.Fatalf("got %d diagnostics, want 1: %+v", len(diags), diags)
+	}
+	if d := diags[0]; d.Line != 10 || d.TestName != "CheckB" || d.Severity != "warning" {
+		t.Errorf("got %s:%d in %q with severity %q, want p_test.go:10 in \"CheckB\" with severity \"warning\"", d.File, d.Line, d.TestName, d.Severity)
+	}
+
+	opts.Severity = "fatal"
+	if _, err := nodefertest.CheckFileWithOptions(fset, file, info, opts); err == nil {
+		t.Error("got no error for an invalid severity")
+	}
+}
+
+// TestRunWithOptions is a test for an analyzer running the checks with RunWithOptions.
+func TestRunWithOptions(t *testing.T) {
+	// The flags are not consulted
+	setFlag(t, "exclude-functions", "TestOther")
+	wrapped := &analysis.Analyzer{
+		Name:       "wrapped",
+		Doc:        "nodefertest with fixed options",
+		Requires:   nodefertest.Analyzer.Requires,
+		FactTypes:  nodefertest.Analyzer.FactTypes,
+		ResultType: nodefertest.Analyzer.ResultType,
+		Run: func(pass *analysis.Pass) (any, error) {
+			opts := nodefertest.DefaultOptions()
+			opts.OnlyTestFiles = false
+			opts.ExcludeFunctions = []string{"TestLegacy", "TestFixture"}
+			opts.AllowedDeferCalls = []string{"os.RemoveAll", "(*os.File).Close"}
+			return nodefertest.RunWithOptions(pass, opts)
+		},
+	}
+	analysistest.Run(t, analysistest.TestData(), wrapped, "config")
+}
+
+// TestBodylessDecl is a test that declarations without a body, such as functions
+// implemented in assembly, are skipped.
+func TestBodylessDecl(t *testing.T) {
+	const src = `package p
+
+import "testing"
+
+func TestStub(t *testing.T)
+
+func helperStub(t *testing.T)
+
+func TestA(t *testing.T) {
+	helperStub(t)
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p_test.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("p", fset, []*ast.File{file}, info); err != nil {
+		t.Fatal(err)
+	}
+
+	setFlag(t, "check-helpers", "true")
+	if diags := nodefertest.CheckFile(fset, file, info); len(diags) != 0 {
+		t.Errorf("got diagnostics %+v, want none", diags)
+	}
+}
+
+// TestBrokenAST is a test that the partially parsed code of unsaved editor buffers,
+// with nil parameter lists and types, is handled without panicking.
+func TestBrokenAST(t *testing.T) {
+	deferStmt := func() *ast.DeferStmt {
+		return &ast.DeferStmt{Call: &ast.CallExpr{Fun: ast.NewIdent("cleanup")}}
+	}
+	file := &ast.File{
+		Name: ast.NewIdent("p"),
+		Decls: []ast.Decl{
+			// func TestNilParams
+			&ast.FuncDecl{
+				Name: ast.NewIdent("TestNilParams"),
+				Type: &ast.FuncType{},
+				Body: &ast.BlockStmt{List: []ast.Stmt{deferStmt()}},
+			},
+			// func TestNilType(t)
+			&ast.FuncDecl{
+				Name: ast.NewIdent("TestNilType"),
+				Type: &ast.FuncType{Params: &ast.FieldList{List: []*ast.Field{
+					{Names: []*ast.Ident{ast.NewIdent("t")}},
+				}}},
+				Body: &ast.BlockStmt{List: []ast.Stmt{
+					deferStmt(),
+					&ast.ExprStmt{X: &ast.FuncLit{
+						Type: &ast.FuncType{Params: &ast.FieldList{List: []*ast.Field{{}}}},
+						Body: &ast.BlockStmt{List: []ast.Stmt{deferStmt()}},
+					}},
+					&ast.ExprStmt{X: &ast.FuncLit{
+						Type: &ast.FuncType{},
+						Body: &ast.BlockStmt{List: []ast.Stmt{deferStmt()}},
+					}},
+				}},
+			},
+		},
+	}
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+
+	for _, flag := range []string{"strict-signature", "check-helpers"} {
+		t.Run(flag, func(t *testing.T) {
+			setFlag(t, flag, "true")
+			if diags := nodefertest.CheckFile(token.NewFileSet(), file, info); len(diags) != 0 {
+				t.Errorf("got diagnostics %+v, want none", diags)
+			}
+		})
+	}
+}
+
+// TestDiagnosticFields is a test for the kind, range, and suggested fix of the
+// diagnostics for a couple of defer shapes.
+func TestDiagnosticFields(t *testing.T) {
+	const src = `package p
+
+import "testing"
+
+func TestA(t *testing.T) {
+	defer cleanup()
+	for range 2 {
+		defer t.Log("done")
+	}
+}
+
+func cleanup() {}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p_test.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("p", fset, []*ast.File{file}, info); err != nil {
+		t.Fatal(err)
+	}
+
+	diags := nodefertest.CheckFile(fset, file, info)
+	if len(diags) != 2 {
+		t.Fatalf("got %d diagnostics, want 2: %+v", len(diags), diags)
+	}
+
+	text := func(pos, end token.Pos) string {
+		return src[fset.Position(pos).Offset:fset.Position(end).Offset]
+	}
+	for i, want := range []struct {
+		kind, text string
+		edits      []string
+	}{
+		{nodefertest.KindDefer, "defer cleanup()", []string{"t.Cleanup(", ")"}},
+		{nodefertest.KindLoop, `defer t.Log("done")`, []string{"t.Cleanup(func() { ", " })"}},
+	} {
+		d := diags[i]
+		if d.Kind != want.kind {
+			t.Errorf("diagnostic %d: got kind %q, want %q", i, d.Kind, want.kind)
+		}
+		if got := text(d.Pos, d.End); got != want.text {
+			t.Errorf("diagnostic %d: got range %q, want %q", i, got, want.text)
+		}
+		if d.SuggestedFix == nil {
+			t.Errorf("diagnostic %d: got no suggested fix", i)
+			continue
+		}
+		var edits []string
+		for _, e := range d.SuggestedFix.Edits {
+			edits = append(edits, e.NewText)
+		}
+		if !slices.Equal(edits, want.edits) {
+			t.Errorf("diagnostic %d: got edits %q, want %q", i, edits, want.edits)
+		}
+	}
+}
+
+// TestHasCleanupCall is a test for recognizing conditional and unconditional t.Cleanup calls.
+func TestHasCleanupCall(t *testing.T) {
+	const src = `package p
+
+import "testing"
+
+func TestUnconditional(t *testing.T) {
+	t.Cleanup(func() {})
+}
+
+func BenchmarkConditional(b *testing.B) {
+	if b.N > 1 {
+		b.Cleanup(func() {})
+	}
+}
+
+func TestInClosure(t *testing.T) {
+	func() { t.Cleanup(func() {}) }()
+}
+
+func TestNone(t *testing.T) {
+	t.Log("no cleanup")
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p_test.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Uses: make(map[*ast.Ident]types.Object),
+	}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("p", fset, []*ast.File{file}, info); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{
+		"TestUnconditional":    true,
+		"BenchmarkConditional": true,
+		"TestInClosure":        false,
+		"TestNone":             false,
+	}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		if got := nodefertest.HasCleanupCall(info, fn.Body); got != want[fn.Name.Name] {
+			t.Errorf("%s: got %v, want %v", fn.Name.Name, got, want[fn.Name.Name])
+		}
+	}
+}
+
+// TestMessage is a test for Analyzer with a custom -message template.
+func TestMessage(t *testing.T) {
+	setFlag(t, "message", "{{.FuncName}}: use {{.CleanupName}}, see https://wiki.example.com/defer")
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "message")
+
+	for _, invalid := range []string{"{{.FuncName", "{{.Unknown}}"} {
+		if err := nodefertest.Analyzer.Flags.Set("message", invalid); err == nil {
+			t.Errorf("expected an error for the invalid template %q", invalid)
+		}
+	}
+}
+
+// TestIgnoreEmptyDefers is a test for Analyzer with -ignore-empty-defers.
+func TestIgnoreEmptyDefers(t *testing.T) {
+	setFlag(t, "ignore-empty-defers", "true")
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "emptydefer")
+}
+
+// TestSummary is a test for the summary reported with -summary.
+func TestSummary(t *testing.T) {
+	setFlag(t, "summary", "true")
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	results := analysistest.Run(t, testdata, nodefertest.Analyzer, "summary")
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+
+	var summaries, flagged int
+	for _, d := range results[0].Diagnostics {
+		if d.Category == "summary" {
+			summaries++
+		} else {
+			flagged++
+		}
+	}
+	if summaries != 1 {
+		t.Errorf("got %d summaries, want 1", summaries)
+	}
+	if diags := results[0].Result.([]nodefertest.Diagnostic); flagged != len(diags) {
+		t.Errorf("got %d flagged sites, want %d as in the result", flagged, len(diags))
+	}
+}
+
+// TestStrictSignature is a test for Analyzer with -strict-signature.
+func TestStrictSignature(t *testing.T) {
+	setFlag(t, "strict-signature", "true")
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "strict")
+}
+
+// TestRespectIntentionalComment is a test for Analyzer with -respect-intentional-comment.
+func TestRespectIntentionalComment(t *testing.T) {
+	setFlag(t, "respect-intentional-comment", "true")
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "intentional")
+}
+
+// TestDiagnosticOrder is a test for the order of the diagnostics of Analyzer.
+func TestDiagnosticOrder(t *testing.T) {
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	results := analysistest.Run(t, testdata, nodefertest.Analyzer, "order")
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+
+	reported := results[0].Diagnostics
+	if len(reported) != 6 {
+		t.Fatalf("got %d diagnostics, want 6", len(reported))
+	}
+	for i := 1; i < len(reported); i++ {
+		if reported[i-1].Pos >= reported[i].Pos {
+			t.Errorf("diagnostic %d at %v is reported after diagnostic %d at %v", i, reported[i].Pos, i-1, reported[i-1].Pos)
+		}
+	}
+
+	diags := results[0].Result.([]nodefertest.Diagnostic)
+	for i, d := range diags {
+		if want := results[0].Action.Package.Fset.Position(reported[i].Pos).Line; d.Line != want {
+			t.Errorf("result %d: got line %d, want %d", i, d.Line, want)
+		}
+	}
+}
+
+// TestOnlyTestFiles is a test for Analyzer with the default -only-test-files.
+func TestOnlyTestFiles(t *testing.T) {
+	setFlag(t, "only-test-files", "true")
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "onlytest")
+}
+
+// TestMinGo is a test for the fixes of Analyzer when targeting Go before 1.14, from the
+// go directive of the module or from -min-go.
+func TestMinGo(t *testing.T) {
+	assertNoFixes := func(t *testing.T, diags []analysis.Diagnostic, want int) {
+		t.Helper()
+		if len(diags) != want {
+			t.Errorf("got %d diagnostics, want %d", len(diags), want)
+		}
+		for _, d := range diags {
+			if len(d.SuggestedFixes) > 0 || len(d.Related) > 0 {
+				t.Errorf("%s: got fixes %v and related %v, want none", d.Message, d.SuggestedFixes, d.Related)
+			}
+		}
+	}
+
+	// analysistest does not load the module, which holds the go directive
+	t.Run("go directive", func(t *testing.T) {
+		cfg := &packages.Config{
+			Mode: packages.LoadAllSyntax | packages.NeedModule,
+			Dir:  filepath.Join(analysistest.TestData(), "src", "oldgo"),
+		}
+		pkgs, err := packages.Load(cfg, ".")
+		if err != nil {
+			t.Fatal(err)
+		}
+		graph, err := checker.Analyze([]*analysis.Analyzer{nodefertest.Analyzer}, pkgs, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertNoFixes(t, graph.Roots[0].Diagnostics, 2)
+	})
+
+	t.Run("flag", func(t *testing.T) {
+		setFlag(t, "min-go", "1.13")
+		testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+		results := analysistest.Run(t, testdata, nodefertest.Analyzer, "related")
+		assertNoFixes(t, results[0].Diagnostics, 3)
+	})
+}
+
+// TestLoopVarGoVersion is a test for the loop variables captured by deferred functions,
+// which are only reported when targeting Go before 1.22.
+func TestLoopVarGoVersion(t *testing.T) {
+	// analysistest does not load the module, which holds the go directive
+	setFlag(t, "min-go", "1.21")
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "loopvar")
+}
+
+// TestNoDuplicates is a test that each defer is reported once, however deeply nested.
+func TestNoDuplicates(t *testing.T) {
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	results := analysistest.Run(t, testdata, nodefertest.Analyzer, "nesting")
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+
+	seen := make(map[token.Pos]bool)
+	for _, d := range results[0].Diagnostics {
+		if seen[d.Pos] {
+			t.Errorf("duplicate diagnostic at %v: %s", results[0].Action.Package.Fset.Position(d.Pos), d.Message)
+		}
+		seen[d.Pos] = true
+	}
+	if len(seen) != 5 {
+		t.Errorf("got %d defer sites, want 5", len(seen))
+	}
+}
+
+// TestMaxDepth is a test for Analyzer with -max-depth on subtests nested 5000 deep.
+func TestMaxDepth(t *testing.T) {
+	const depth = 5000
+	var src strings.Builder
+	src.WriteString("package p\n\nimport \"testing\"\n\nfunc TestDeep(t *testing.T) {\n\tdefer t.Log()\n")
+	for range depth {
+		src.WriteString("t.Run(\"\", func(t *testing.T) {\n")
+	}
+	src.WriteString("defer t.Log()\n")
+	for range depth {
+		src.WriteString("})\n")
+	}
+	src.WriteString("}\n")
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p_test.go", src.String(), parser.SkipObjectResolution)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("p", fset, []*ast.File{file}, info); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		maxDepth string
+		want     []string
+	}{
+		{"1000", []string{nodefertest.KindDefer, nodefertest.KindMaxDepth}},
+		{"0", []string{nodefertest.KindDefer, nodefertest.KindDefer}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.maxDepth, func(t *testing.T) {
+			setFlag(t, "max-depth", tt.maxDepth)
+			var kinds []string
+			for _, d := range nodefertest.CheckFile(fset, file, info) {
+				kinds = append(kinds, d.Kind)
+			}
+			if !slices.Equal(kinds, tt.want) {
+				t.Errorf("got kinds %q, want %q", kinds, tt.want)
+			}
+		})
+	}
+}
+
+// TestIgnoreUnderShortGuard is a test for Analyzer with -ignore-under-short-guard.
+func TestIgnoreUnderShortGuard(t *testing.T) {
+	setFlag(t, "ignore-under-short-guard", "true")
+	testdata := testutil.WithModules(t, analysistest.TestData(), nil)
+	analysistest.Run(t, testdata, nodefertest.Analyzer, "shortguard")
+}
+
+// TestDiagnosticRange is a test that diagnostics span the whole defer statement.
+func TestDiagnosticRange(t *testing.T) {
+	results := analysistest.Run(t, analysistest.TestData(), nodefertest.Analyzer, "related")
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+
+	fset := results[0].Action.Package.Fset
+	for _, d := range results[0].Diagnostics {
+		start, end := fset.Position(d.Pos), fset.Position(d.End)
+		src, err := os.ReadFile(start.Filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stmt := string(src[start.Offset:end.Offset])
+		if !strings.HasPrefix(stmt, "defer ") || !strings.HasSuffix(stmt, ")") {
+			t.Errorf("%v: got range %q, want the whole defer statement", start, stmt)
+		}
+	}
+}
+
+// TestOptIn is a test for Analyzer with -opt-in.
+func TestOptIn(t *testing.T) {
+	// Both files are checked by default
+	results := analysistest.Run(discardErrors{}, analysistest.TestData(), nodefertest.Analyzer, "optin")
+	if n := len(results[0].Diagnostics); n != 2 {
+		t.Errorf("got %d diagnostics without -opt-in, want 2", n)
+	}
+
+	setFlag(t, "opt-in", "true")
+	analysistest.Run(t, analysistest.TestData(), nodefertest.Analyzer, "optin")
+}
+
+// TestShadowedFix is a test that fixes use the parameter of the subtest in scope.
+func TestShadowedFix(t *testing.T) {
+	results := analysistest.Run(t, analysistest.TestData(), nodefertest.Analyzer, "fix")
+	var fixes []string
+	for _, d := range results[0].Result.([]nodefertest.Diagnostic) {
+		if d.TestName == "TestShadowedTable" {
+			fixes = append(fixes, d.Fix)
+		}
+	}
+	want := []string{"subT.Cleanup(cleanup)", "t.Cleanup(cleanup)"}
+	if !slices.Equal(fixes, want) {
+		t.Errorf("got fixes %q, want %q", fixes, want)
+	}
+}
+
+// TestMethodNames is a test for Analyzer with -method-names.
+func TestMethodNames(t *testing.T) {
+	setFlag(t, "method-names", "Run")
+	analysistest.Run(t, analysistest.TestData(), nodefertest.Analyzer, "runner")
+}
+
+// TestNoteUnlock is a test for Analyzer with -note-unlock.
+func TestNoteUnlock(t *testing.T) {
+	setFlag(t, "note-unlock", "true")
+	analysistest.Run(t, analysistest.TestData(), nodefertest.Analyzer, "unlocknote")
+}
+
+// TestDisallowTimerControls is a test for Analyzer with -allow-timer-controls=false.
+func TestDisallowTimerControls(t *testing.T) {
+	setFlag(t, "allow-timer-controls", "false")
+	analysistest.Run(t, analysistest.TestData(), nodefertest.Analyzer, "timers")
 }
@@ -0,0 +1,199 @@
+package nodefertest
+
+import (
+	"fmt"
+	"regexp"
+	"text/template"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// Options configures the checks, for programs that run them with RunWithOptions or
+// CheckFileWithOptions rather than through the flags of Analyzer. Most fields are named
+// after their flag, as CheckHelpers is -check-helpers; the others name their flag. -config
+// has no field, since it only sets the other flags. DefaultOptions returns the defaults of
+// the flags.
+type Options struct {
+	// CheckBenchmarks checks Benchmark functions
+	CheckBenchmarks bool
+	// AllowUnlock allows deferred sync.Mutex and sync.RWMutex unlocks
+	AllowUnlock bool
+	// NoteUnlock reports allowed unlocks as information
+	NoteUnlock bool
+	// AllowRecover allows deferred function literals that only recover from a panic
+	AllowRecover bool
+	// AllowTimerControls allows deferred b.StopTimer, b.StartTimer, and b.ResetTimer calls
+	AllowTimerControls bool
+	// AllowSpanEnd allows deferred calls of SpanEndMethod, such as span.End()
+	AllowSpanEnd bool
+	// SpanEndMethod is the name of the method ending a trace span or region
+	SpanEndMethod string
+	// RequireFatal only reports defers in test functions that may stop early
+	RequireFatal bool
+	// FatalOrder only reports defers followed by a call that may stop the test
+	FatalOrder bool
+	// CheckExamples checks Example functions
+	CheckExamples bool
+	// SkipOutputExamples skips examples with an // Output: comment under CheckExamples
+	SkipOutputExamples bool
+	// CheckHelpers checks helper functions taking a testing parameter
+	CheckHelpers bool
+	// IgnoreTBHelpers skips helpers taking only testing.TB under CheckHelpers
+	IgnoreTBHelpers bool
+	// Prefixes are the function name prefixes that identify test functions
+	Prefixes []string
+	// Severity is the severity of the diagnostics, one of error, warning, or info;
+	// empty means error
+	Severity string
+	// FlagGoroutineDefers checks defers in goroutines receiving a *testing.T
+	FlagGoroutineDefers bool
+	// CheckTFields checks methods, and closures taking values, of types storing a testing
+	// parameter in a field, embedded or not
+	CheckTFields bool
+	// AllowedDeferCalls is -allow-calls, the deferred calls to allow, as qualified names
+	// like os.RemoveAll or (*os.File).Close, or selectors like f.Close
+	AllowedDeferCalls []string
+	// AllowPackages are the import paths of the packages whose functions may be deferred
+	AllowPackages []string
+	// ExcludeFiles matches the names of files that are not checked, if set
+	ExcludeFiles *regexp.Regexp
+	// ExcludeDirs are substrings, such as vendor/, of the slash-separated paths of the
+	// files that are not checked
+	ExcludeDirs []string
+	// IgnoreEmptyDefers allows deferred empty function literals
+	IgnoreEmptyDefers bool
+	// StrictSignature only treats functions of exactly func(*testing.T) and the like as tests
+	StrictSignature bool
+	// RespectIntentionalComment allows defers right below a comment matching IntentionalComment
+	RespectIntentionalComment bool
+	// IntentionalComment matches the comments explaining an intentional defer
+	IntentionalComment *regexp.Regexp
+	// OnlyTestFiles only checks the test functions of _test.go files
+	OnlyTestFiles bool
+	// MinGo is the oldest Go version the code must build with, or empty for the go directive
+	MinGo string
+	// IgnoreUnderShortGuard allows defers guarded by testing.Short()
+	IgnoreUnderShortGuard bool
+	// Ginkgo is -ginkgo, which checks the closures passed to Ginkgo nodes
+	Ginkgo bool
+	// OptIn only checks the files with the opt-in marker comment
+	OptIn bool
+	// PrintFixes writes the suggested fixes to standard output instead of reporting them
+	PrintFixes bool
+	// Summary reports the number of diagnostics per test function for each package
+	Summary bool
+	// MethodNames are the names of the methods that runners call as the body of a test,
+	// such as Run; methods with these names taking a testing parameter are checked
+	MethodNames []string
+	// MaxDepth is the number of nested test and subtest functions checked, or 0 for no limit
+	MaxDepth int
+	// ExcludeFunctions is -exclude-functions, the names of the test functions that are
+	// not checked
+	ExcludeFunctions []string
+	// Message is the template of the message for defers in test functions, with the
+	// fields FuncName, CleanupName, and Message; nil for the default message
+	Message *template.Template
+	// Explain writes why each function declaration is checked or skipped to standard error
+	Explain bool
+}
+
+// DefaultOptions returns the options of Analyzer when no flags are set
+func DefaultOptions() Options {
+	return Options{
+		CheckBenchmarks:    true,
+		AllowUnlock:        true,
+		AllowRecover:       true,
+		AllowTimerControls: true,
+		SpanEndMethod:      "End",
+		Prefixes:           []string{"Test", "Benchmark", "Fuzz"},
+		Severity:           "error",
+		IntentionalComment: regexp.MustCompile(`(?i)intentional`),
+		OnlyTestFiles:      true,
+		MaxDepth:           1000,
+	}
+}
+
+// flagOptions returns the options set by the flags of Analyzer
+func flagOptions() Options {
+	return Options{
+		CheckBenchmarks:           checkBenchmarks,
+		AllowUnlock:               allowUnlock,
+		NoteUnlock:                noteUnlock,
+		AllowRecover:              allowRecover,
+		AllowTimerControls:        allowTimerControls,
+		AllowSpanEnd:              allowSpanEnd,
+		SpanEndMethod:             spanEndMethod,
+		RequireFatal:              requireFatal,
+		FatalOrder:                fatalOrder,
+		CheckExamples:             checkExamples,
+		SkipOutputExamples:        skipOutputExamples,
+		CheckHelpers:              checkHelpers,
+		IgnoreTBHelpers:           ignoreTBHelpers,
+		Prefixes:                  prefixes,
+		Severity:                  string(severity),
+		FlagGoroutineDefers:       flagGoroutineDefers,
+		CheckTFields:              checkTFields,
+		AllowedDeferCalls:         allowCalls,
+		AllowPackages:             allowPackages,
+		ExcludeFiles:              excludeFiles.re,
+		ExcludeDirs:               excludeDirs,
+		IgnoreEmptyDefers:         ignoreEmptyDefers,
+		StrictSignature:           strictSignature,
+		RespectIntentionalComment: respectIntentionalComment,
+		IntentionalComment:        intentionalComment.re,
+		OnlyTestFiles:             onlyTestFiles,
+		MinGo:                     minGo,
+		IgnoreUnderShortGuard:     ignoreUnderShortGuard,
+		Ginkgo:                    checkGinkgo,
+		OptIn:                     optIn,
+		PrintFixes:                printFixes,
+		Summary:                   summary,
+		MethodNames:               methodNames,
+		MaxDepth:                  maxDepth,
+		ExcludeFunctions:          excludeFuncs,
+		Message:                   message.tmpl,
+		Explain:                   explain,
+	}
+}
+
+// validate checks the options, defaulting an empty Severity to error
+func (o *Options) validate() error {
+	switch o.Severity {
+	case "":
+		o.Severity = "error"
+	case "error", "warning", "info":
+	default:
+		return fmt.Errorf("invalid severity %q: must be error, warning, or info", o.Severity)
+	}
+	if o.MaxDepth < 0 {
+		return fmt.Errorf("invalid max depth %d: must not be negative", o.MaxDepth)
+	}
+	return nil
+}
+
+// RunWithOptions checks the package of the pass with opts instead of the flags of Analyzer,
+// for analyzers wrapping this one. It reports the diagnostics to the pass and returns them,
+// like the result of Analyzer. The pass must provide the facts of Analyzer, and may provide
+// the result of the inspect analyzer.
+func RunWithOptions(pass *analysis.Pass, opts Options) ([]Diagnostic, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	insp, ok := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	if !ok {
+		insp = inspector.New(pass.Files)
+	}
+	diags := check(pass, &opts, insp)
+	if opts.Summary && len(diags) > 0 {
+		reportSummary(pass, diags)
+	}
+	if opts.PrintFixes {
+		if err := writeFixes(fixOutput, pass, diags); err != nil {
+			return nil, err
+		}
+	}
+	return diags, nil
+}
@@ -0,0 +1,114 @@
+package nodefertest
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// hasParallelSubtest checks if the test function body starts a subtest with
+// t.Run(name, func(t *testing.T) {...}) whose function calls t.Parallel(), or with
+// t.Run(name, parallelCase), where parallelCase is one of the subtests declared in the
+// package that calls it
+func hasParallelSubtest(pass *analysis.Pass, body *ast.BlockStmt, subtests map[types.Object]*ast.FuncDecl) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+
+		switch node := n.(type) {
+		case *ast.FuncLit:
+			// Subtests of subtests only delay the subtest, not this function
+			return !hasFuncLitTestingTParam(pass, node)
+		case *ast.CallExpr:
+			if !isTestingMethod(pass, node, "Run") {
+				return true
+			}
+			for _, arg := range node.Args {
+				switch arg := ast.Unparen(arg).(type) {
+				case *ast.FuncLit:
+					found = hasFuncLitTestingTParam(pass, arg) && callsParallel(pass, arg.Body)
+				case *ast.Ident:
+					decl := subtests[pass.TypesInfo.Uses[arg]]
+					found = decl != nil && callsParallel(pass, decl.Body)
+				}
+				if found {
+					return false
+				}
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// callsParallel checks if the subtest body calls t.Parallel() outside nested function literals
+func callsParallel(pass *analysis.Pass, body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+
+		switch node := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.CallExpr:
+			if isTestingMethod(pass, node, "Parallel") {
+				found = true
+				return false
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// parallelCalls returns the t.Parallel() calls in the body outside nested function
+// literals, in source order
+func parallelCalls(pass *analysis.Pass, body *ast.BlockStmt) []*ast.CallExpr {
+	var found []*ast.CallExpr
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.CallExpr:
+			if isTestingMethod(pass, node, "Parallel") {
+				found = append(found, node)
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// hasCleanupCall checks if the body registers a function with t.Cleanup or b.Cleanup
+// outside nested function literals, whether or not the call is conditional
+func hasCleanupCall(pass *analysis.Pass, body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+
+		switch node := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.CallExpr:
+			if isTestingMethod(pass, node, "Cleanup") {
+				found = true
+				return false
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// isTestingMethod checks if the call is the named method of a testing package type
+func isTestingMethod(pass *analysis.Pass, call *ast.CallExpr, name string) bool {
+	fn := calledFunc(pass, call)
+	return fn != nil && fn.Pkg() != nil && fn.Pkg().Path() == "testing" && fn.Name() == name
+}
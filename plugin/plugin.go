@@ -0,0 +1,57 @@
+// Package plugin exposes nodefertest as a golangci-lint plugin.
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/s4s7/nodefertest"
+	"golang.org/x/tools/go/analysis"
+)
+
+// New returns the nodefertest analyzer configured from conf, which holds the
+// linter settings from the golangci-lint configuration. Each key is the name of
+// an analyzer flag, such as "prefixes" or "check-benchmarks"; list values are
+// joined with commas.
+func New(conf any) ([]*analysis.Analyzer, error) {
+	if conf != nil {
+		settings, ok := conf.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("nodefertest: invalid settings type %T", conf)
+		}
+
+		for name, value := range settings {
+			if err := setFlag(name, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return []*analysis.Analyzer{nodefertest.Analyzer}, nil
+}
+
+// setFlag sets the analyzer flag from a setting value
+func setFlag(name string, value any) error {
+	if nodefertest.Analyzer.Flags.Lookup(name) == nil {
+		return fmt.Errorf("nodefertest: unknown setting %q", name)
+	}
+
+	var s string
+	switch v := value.(type) {
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		s = strings.Join(items, ",")
+	case []string:
+		s = strings.Join(v, ",")
+	default:
+		s = fmt.Sprint(v)
+	}
+
+	if err := nodefertest.Analyzer.Flags.Set(name, s); err != nil {
+		return fmt.Errorf("nodefertest: invalid setting %q: %w", name, err)
+	}
+	return nil
+}
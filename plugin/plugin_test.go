@@ -0,0 +1,63 @@
+package plugin_test
+
+import (
+	"testing"
+
+	"github.com/s4s7/nodefertest"
+	"github.com/s4s7/nodefertest/plugin"
+)
+
+// TestNew is a test for New.
+func TestNew(t *testing.T) {
+	restoreFlags(t, "prefixes", "check-benchmarks")
+
+	analyzers, err := plugin.New(map[string]any{
+		"prefixes":         []any{"Test", "IntegrationTest"},
+		"check-benchmarks": false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(analyzers) != 1 || analyzers[0] != nodefertest.Analyzer {
+		t.Fatalf("got %v, want [nodefertest.Analyzer]", analyzers)
+	}
+
+	for name, want := range map[string]string{
+		"prefixes":         "Test,IntegrationTest",
+		"check-benchmarks": "false",
+	} {
+		if got := nodefertest.Analyzer.Flags.Lookup(name).Value.String(); got != want {
+			t.Errorf("flag %s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+// TestNewInvalid is a test for New with invalid settings.
+func TestNewInvalid(t *testing.T) {
+	restoreFlags(t, "severity")
+
+	for name, conf := range map[string]any{
+		"not a map":       "prefixes",
+		"unknown setting": map[string]any{"no-such-flag": true},
+		"invalid value":   map[string]any{"severity": "fatal"},
+	} {
+		if _, err := plugin.New(conf); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// restoreFlags restores the Analyzer flags after the test.
+func restoreFlags(t *testing.T, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		f := nodefertest.Analyzer.Flags.Lookup(name)
+		old := f.Value.String()
+		t.Cleanup(func() {
+			if err := f.Value.Set(old); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
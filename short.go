@@ -0,0 +1,67 @@
+package nodefertest
+
+import (
+	"go/ast"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// isUnderShortGuard checks if the innermost node of the stack, within the function fn,
+// only runs in long mode or only in short mode: inside an if statement whose condition
+// calls testing.Short(), or after an if testing.Short() { return } guard
+func isUnderShortGuard(pass *analysis.Pass, stack []ast.Node, fn ast.Node) bool {
+	for i := len(stack) - 2; i >= 0 && stack[i] != fn; i-- {
+		switch node := stack[i].(type) {
+		case *ast.IfStmt:
+			// The condition itself is evaluated in both modes
+			if stack[i+1] != node.Cond && callsShort(pass, node.Cond) {
+				return true
+			}
+		case *ast.BlockStmt:
+			for _, stmt := range node.List {
+				if stmt == stack[i+1] {
+					break
+				}
+				if isShortGuard(pass, stmt) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// isShortGuard checks if the statement is an if statement on testing.Short() that
+// leaves the function, as in if testing.Short() { t.Skip() }
+func isShortGuard(pass *analysis.Pass, stmt ast.Stmt) bool {
+	ifStmt, ok := stmt.(*ast.IfStmt)
+	if !ok || !callsShort(pass, ifStmt.Cond) || len(ifStmt.Body.List) == 0 {
+		return false
+	}
+
+	switch last := ifStmt.Body.List[len(ifStmt.Body.List)-1].(type) {
+	case *ast.ReturnStmt:
+		return true
+	case *ast.ExprStmt:
+		call, ok := last.X.(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		return isTestingMethod(pass, call, "Skip") || isTestingMethod(pass, call, "Skipf") || isTestingMethod(pass, call, "SkipNow")
+	}
+	return false
+}
+
+// callsShort checks if the expression calls testing.Short()
+func callsShort(pass *analysis.Pass, expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if fn := calledFunc(pass, call); fn != nil && fn.Pkg() != nil && fn.Pkg().Path() == "testing" && fn.Name() == "Short" {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
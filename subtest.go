@@ -0,0 +1,41 @@
+package nodefertest
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// namedSubtests finds the functions of the files passed by name to t.Run, as in
+// t.Run("x", tableCase), and returns their declarations, so that they are checked like
+// the function literals of subtests
+func namedSubtests(pass *analysis.Pass, files []*ast.File) map[types.Object]*ast.FuncDecl {
+	run := make(map[types.Object]bool)
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) != 2 || !isTestingMethod(pass, call, "Run") {
+				return true
+			}
+			ident, ok := ast.Unparen(call.Args[1]).(*ast.Ident)
+			if !ok {
+				return true
+			}
+			if fn, ok := pass.TypesInfo.Uses[ident].(*types.Func); ok {
+				run[fn] = true
+			}
+			return true
+		})
+	}
+
+	subtests := make(map[types.Object]*ast.FuncDecl)
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Body != nil && run[pass.TypesInfo.Defs[funcDecl.Name]] {
+				subtests[pass.TypesInfo.Defs[funcDecl.Name]] = funcDecl
+			}
+		}
+	}
+	return subtests
+}
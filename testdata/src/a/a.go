@@ -6,7 +6,7 @@ import "testing"
 // When t.Fatal or t.FailNow is called, they exit immediately via runtime.Goexit(),
 // preventing deferred functions from running.
 func TestWithDefer(t *testing.T) {
-	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestWithDefer\""
 
 	// If t.Fatal is called here, the deferred cleanup() will not run
 	// because t.Fatal calls runtime.Goexit() immediately
@@ -17,11 +17,11 @@ func TestWithDefer(t *testing.T) {
 
 // TestMultipleDefers shows multiple defer statements in a test
 func TestMultipleDefers(t *testing.T) {
-	defer func() { // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+	defer func() { // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestMultipleDefers\""
 		// cleanup logic
 	}()
 
-	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestMultipleDefers\""
 
 	if someCondition() {
 		t.FailNow() // none of the deferred functions will run
@@ -53,7 +53,7 @@ func TestMultipleCleanups(t *testing.T) {
 // TestSubtestWithDefer shows defer is still problematic in subtests
 func TestSubtestWithDefer(t *testing.T) {
 	t.Run("subtest", func(t *testing.T) {
-		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestSubtestWithDefer\""
 
 		if someCondition() {
 			t.Fatal("failed")
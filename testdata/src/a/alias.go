@@ -0,0 +1,19 @@
+package a
+
+import (
+	tst "testing"
+)
+
+// TestAliasedImport shows defer in a test using an aliased testing import
+func TestAliasedImport(t *tst.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestAliasedImport\""
+
+	t.Run("subtest", func(t *tst.T) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestAliasedImport\""
+	})
+}
+
+// BenchmarkAliasedImport shows defer in a benchmark using an aliased testing import
+func BenchmarkAliasedImport(b *tst.B) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"BenchmarkAliasedImport\""
+}
@@ -0,0 +1,34 @@
+package a
+
+import "testing"
+
+// BenchmarkDeferInLoop shows defers in the loops running the iterations of a benchmark
+func BenchmarkDeferInLoop(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		defer cleanup() // want "avoid defer in the b.N loop of a benchmark; every iteration defers another call, which piles up b.N calls until the benchmark function returns and distorts the measurement, so call it at the end of the iteration instead in test function \"BenchmarkDeferInLoop\""
+	}
+
+	for range b.N {
+		if true {
+			defer cleanup() // want "avoid defer in the b.N loop of a benchmark; every iteration defers another call, which piles up b.N calls until the benchmark function returns and distorts the measurement, so call it at the end of the iteration instead in test function \"BenchmarkDeferInLoop\""
+		}
+	}
+
+	for b.Loop() {
+		defer cleanup() // want "avoid defer in the b.N loop of a benchmark; every iteration defers another call, which piles up b.N calls until the benchmark function returns and distorts the measurement, so call it at the end of the iteration instead in test function \"BenchmarkDeferInLoop\""
+	}
+
+	// Other loops get the general message
+	for range 3 {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in a loop in test functions; deferred calls accumulate until the test function returns instead of running per iteration, and are skipped after t.Fatal/t.FailNow in test function \"BenchmarkDeferInLoop\""
+	}
+}
+
+// BenchmarkDeferInSubLoop shows the loop of a sub-benchmark
+func BenchmarkDeferInSubLoop(b *testing.B) {
+	b.Run("sub", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			defer cleanup() // want "avoid defer in the b.N loop of a benchmark; every iteration defers another call, which piles up b.N calls until the benchmark function returns and distorts the measurement, so call it at the end of the iteration instead in test function \"BenchmarkDeferInSubLoop\""
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package a
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestDeferInCleanup shows defer inside a function registered with t.Cleanup
+func TestDeferInCleanup(t *testing.T) {
+	t.Cleanup(func() {
+		defer cleanup() // want "avoid defer in functions registered with t.Cleanup; call it at the end of the cleanup function, or register it with its own t.Cleanup, which runs after this one, in test function \"TestDeferInCleanup\""
+		cleanup()
+	})
+
+	var mu sync.Mutex
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock() // Allowed like anywhere else
+	})
+
+	t.Cleanup(func() {
+		// Plain literals inside the cleanup function run in their own frame
+		func() {
+			defer cleanup()
+		}()
+	})
+}
+
+// BenchmarkDeferInCleanup shows b.Cleanup is handled the same
+func BenchmarkDeferInCleanup(b *testing.B) {
+	b.Cleanup(func() {
+		defer cleanup() // want "avoid defer in functions registered with t.Cleanup; call it at the end of the cleanup function, or register it with its own t.Cleanup, which runs after this one, in test function \"BenchmarkDeferInCleanup\""
+	})
+}
+
+// notATestCleanup is not a test function, so its cleanup is not checked
+func notATestCleanup(t *testing.T) {
+	t.Cleanup(func() {
+		defer cleanup()
+	})
+}
+
+// TestFatalInCleanup shows t.Fatal in a function registered with t.Cleanup
+func TestFatalInCleanup(t *testing.T) {
+	t.Cleanup(func() {
+		defer cleanup() // want "avoid defer in functions registered with t.Cleanup; call it at the end of the cleanup function, or register it with its own t.Cleanup, which runs after this one, in test function \"TestFatalInCleanup\""
+		if err := closeResource(); err != nil {
+			t.Fatalf("close: %v", err) // want "avoid t.Fatal/t.FailNow in functions registered with t.Cleanup; it skips the rest of the cleanup function, so report with t.Error and continue the cleanup, in test function \"TestFatalInCleanup\""
+		}
+		t.FailNow() // want "avoid t.Fatal/t.FailNow in functions registered with t.Cleanup; it skips the rest of the cleanup function, so report with t.Error and continue the cleanup, in test function \"TestFatalInCleanup\""
+	})
+
+	t.Cleanup(func() {
+		if err := closeResource(); err != nil {
+			t.Error(err) // Reporting without stopping is fine
+		}
+	})
+}
+
+func closeResource() error { return nil }
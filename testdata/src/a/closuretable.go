@@ -0,0 +1,49 @@
+package a
+
+import "testing"
+
+// TestClosureSlice shows subtest closures stored in a slice
+func TestClosureSlice(t *testing.T) {
+	tests := []func(*testing.T){
+		func(t *testing.T) {
+			defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestClosureSlice\""
+		},
+		func(t *testing.T) {
+			t.Cleanup(cleanup)
+		},
+	}
+	for _, tt := range tests {
+		tt(t)
+	}
+}
+
+// TestClosureStructTable shows subtest closures stored in a table of structs
+func TestClosureStructTable(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{
+			name: "first",
+			run: func(t *testing.T) {
+				defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestClosureStructTable\""
+			},
+		},
+		{"second", func(t *testing.T) {
+			defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestClosureStructTable\""
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, tt.run)
+	}
+}
+
+// TestClosureArray shows closures nested in an array of slices
+func TestClosureArray(t *testing.T) {
+	groups := [1][]func(testing.TB){
+		{func(tb testing.TB) {
+			defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestClosureArray\""
+		}},
+	}
+	_ = groups
+}
@@ -0,0 +1,39 @@
+package a
+
+import "testing"
+
+// TestBenchmarkInTest shows a *testing.B literal nested in a test function
+func TestBenchmarkInTest(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestBenchmarkInTest\""
+
+	testing.Benchmark(func(b *testing.B) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestBenchmarkInTest\""
+		b.Run("sub", func(b *testing.B) {
+			defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestBenchmarkInTest\""
+		})
+	})
+}
+
+// BenchmarkTestInBenchmark shows a *testing.T literal nested in a benchmark
+func BenchmarkTestInBenchmark(b *testing.B) {
+	check := func(t *testing.T) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"BenchmarkTestInBenchmark\""
+		t.Run("sub", func(t *testing.T) {
+			defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"BenchmarkTestInBenchmark\""
+		})
+	}
+	_ = check
+
+	b.Run("sub", func(b *testing.B) {
+		// A testing.TB literal in a sub-benchmark is its own context too
+		assert := func(tb testing.TB) {
+			defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"BenchmarkTestInBenchmark\""
+		}
+		assert(b)
+
+		// Plain literals inside it are not checked
+		func() {
+			defer cleanup()
+		}()
+	})
+}
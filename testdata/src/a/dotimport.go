@@ -0,0 +1,34 @@
+package a
+
+import (
+	. "testing"
+)
+
+// TestDotImport shows defer in a test using a dot-imported testing package
+func TestDotImport(t *T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDotImport\""
+
+	t.Run("subtest", func(t *T) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDotImport\""
+	})
+}
+
+// FuzzDotImport shows defer in a fuzz target using a dot-imported testing package
+func FuzzDotImport(f *F) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"FuzzDotImport\""
+}
+
+// BenchmarkDotImport shows defer in a benchmark using a dot-imported testing package
+func BenchmarkDotImport(b *B) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"BenchmarkDotImport\""
+}
+
+// TestDotImportLocalT shows a local type named T is not mistaken for testing.T
+func TestDotImportLocalT(t *T) {
+	type T struct{}
+
+	fn := func(t *T) {
+		defer cleanup() // No warning - *T is the local type, not testing.T
+	}
+	fn(&T{})
+}
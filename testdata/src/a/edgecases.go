@@ -19,7 +19,7 @@ func TestVariablesWithDeferName(t *testing.T) {
 	fmt.Println(defer2, deferCount, shouldDefer, msg, comment)
 
 	// Actual defer statement - this SHOULD be flagged
-	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestVariablesWithDeferName\""
 }
 
 // TestStructFieldsWithDefer shows struct fields named defer
@@ -39,13 +39,13 @@ func TestStructFieldsWithDefer(t *testing.T) {
 	fmt.Printf("%+v\n", cfg)
 
 	// Actual defer - this SHOULD be flagged
-	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestStructFieldsWithDefer\""
 }
 
 // TestDeferInIfBlock shows defer inside if block
 func TestDeferInIfBlock(t *testing.T) {
 	if someCondition() {
-		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferInIfBlock\""
 	}
 	t.Log("test")
 }
@@ -53,18 +53,59 @@ func TestDeferInIfBlock(t *testing.T) {
 // TestDeferInForLoop shows defer inside for loop
 func TestDeferInForLoop(t *testing.T) {
 	for i := 0; i < 3; i++ {
-		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in a loop in test functions; deferred calls accumulate until the test function returns instead of running per iteration, and are skipped after t.Fatal/t.FailNow in test function \"TestDeferInForLoop\""
 	}
 	t.Log("test")
 }
 
+// TestDeferInRangeLoop shows defer nested inside a range loop
+func TestDeferInRangeLoop(t *testing.T) {
+	for range 3 {
+		if someCondition() {
+			defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in a loop in test functions; deferred calls accumulate until the test function returns instead of running per iteration, and are skipped after t.Fatal/t.FailNow in test function \"TestDeferInRangeLoop\""
+		}
+	}
+}
+
+// TestDeferCapturesLoopVar shows deferred function literals capturing the loop variable,
+// which this module declares per iteration since it targets Go 1.22 or later
+func TestDeferCapturesLoopVar(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		defer func() { // want "use t.Cleanup\\(\\) instead of defer in a loop in test functions; deferred calls accumulate until the test function returns instead of running per iteration, and are skipped after t.Fatal/t.FailNow in test function \"TestDeferCapturesLoopVar\""
+			t.Log(i)
+		}()
+	}
+
+	for _, name := range []string{"a", "b"} {
+		defer func() { // want "use t.Cleanup\\(\\) instead of defer in a loop in test functions; deferred calls accumulate until the test function returns instead of running per iteration, and are skipped after t.Fatal/t.FailNow in test function \"TestDeferCapturesLoopVar\""
+			t.Log(name)
+		}()
+	}
+
+	for range 3 {
+		n := 1
+		defer func() { // want "use t.Cleanup\\(\\) instead of defer in a loop in test functions; deferred calls accumulate until the test function returns instead of running per iteration, and are skipped after t.Fatal/t.FailNow in test function \"TestDeferCapturesLoopVar\""
+			t.Log(n) // Not a loop variable
+		}()
+	}
+}
+
+// TestDeferInSubtestInLoop shows a loop outside the subtest does not count
+func TestDeferInSubtestInLoop(t *testing.T) {
+	for _, name := range []string{"a", "b"} {
+		t.Run(name, func(t *testing.T) {
+			defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferInSubtestInLoop\""
+		})
+	}
+}
+
 // TestDeferInSwitchCase shows defer inside switch statement
 func TestDeferInSwitchCase(t *testing.T) {
 	switch {
 	case someCondition():
-		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferInSwitchCase\""
 	default:
-		defer func() {}() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+		defer func() {}() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferInSwitchCase\""
 	}
 	t.Log("test")
 }
@@ -74,16 +115,16 @@ func TestDeferInSelect(t *testing.T) {
 	ch := make(chan bool)
 	select {
 	case <-ch:
-		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferInSelect\""
 	default:
-		defer func() {}() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+		defer func() {}() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferInSelect\""
 	}
 	t.Log("test")
 }
 
 // TestNestedFuncLitWithoutTestingT shows nested function literal without *testing.T
 func TestNestedFuncLitWithoutTestingT(t *testing.T) {
-	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestNestedFuncLitWithoutTestingT\""
 
 	// This function literal doesn't have *testing.T parameter
 	// so defer inside it should not be flagged
@@ -95,12 +136,12 @@ func TestNestedFuncLitWithoutTestingT(t *testing.T) {
 
 // TestNestedFuncLitWithTestingT shows nested function literal with *testing.T
 func TestNestedFuncLitWithTestingT(t *testing.T) {
-	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestNestedFuncLitWithTestingT\""
 
 	// This function literal has *testing.T parameter
 	// so defer inside it should be flagged
 	fn := func(t *testing.T) {
-		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestNestedFuncLitWithTestingT\""
 	}
 	fn(t)
 }
@@ -108,10 +149,10 @@ func TestNestedFuncLitWithTestingT(t *testing.T) {
 // TestDeepNestedFuncLit shows deeply nested function literals
 func TestDeepNestedFuncLit(t *testing.T) {
 	t.Run("outer", func(t *testing.T) {
-		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeepNestedFuncLit\""
 
 		t.Run("inner", func(t *testing.T) {
-			defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+			defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeepNestedFuncLit\""
 
 			// Function without *testing.T
 			fn := func() {
@@ -124,7 +165,7 @@ func TestDeepNestedFuncLit(t *testing.T) {
 
 // BenchmarkWithDefer tests benchmark functions with defer
 func BenchmarkWithDefer(b *testing.B) {
-	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"BenchmarkWithDefer\""
 
 	for i := 0; i < b.N; i++ {
 		// benchmark code
@@ -143,7 +184,7 @@ func BenchmarkWithCleanup(b *testing.B) {
 // BenchmarkSubBenchmarkWithDefer shows defer in sub-benchmark
 func BenchmarkSubBenchmarkWithDefer(b *testing.B) {
 	b.Run("sub", func(b *testing.B) {
-		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"BenchmarkSubBenchmarkWithDefer\""
 
 		for i := 0; i < b.N; i++ {
 			// benchmark code
@@ -153,7 +194,7 @@ func BenchmarkSubBenchmarkWithDefer(b *testing.B) {
 
 // TestDeferWithPanicRecover shows defer with panic/recover
 func TestDeferWithPanicRecover(t *testing.T) {
-	defer func() { // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+	defer func() { // No warning - recovery requires defer
 		if r := recover(); r != nil {
 			t.Errorf("recovered: %v", r)
 		}
@@ -162,6 +203,36 @@ func TestDeferWithPanicRecover(t *testing.T) {
 	// test code
 }
 
+// TestDeferRecoverVariants shows other recover-only defers
+func TestDeferRecoverVariants(t *testing.T) {
+	defer func() { // No warning - recovery requires defer
+		recover()
+	}()
+
+	defer func() { // No warning - recovery requires defer
+		r := recover()
+		if r != nil {
+			t.Log(r)
+		}
+	}()
+}
+
+// TestDeferRecoverWithCleanup shows a defer mixing cleanup and recovery is still flagged
+func TestDeferRecoverWithCleanup(t *testing.T) {
+	defer func() { // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferRecoverWithCleanup\""
+		cleanup()
+		if r := recover(); r != nil {
+			t.Errorf("recovered: %v", r)
+		}
+	}()
+
+	defer func() { // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferRecoverWithCleanup\""
+		if someCondition() {
+			cleanup()
+		}
+	}()
+}
+
 // TestAnonymousFunctionCall shows immediate function call
 func TestAnonymousFunctionCall(t *testing.T) {
 	func() {
@@ -170,13 +241,13 @@ func TestAnonymousFunctionCall(t *testing.T) {
 
 	// Function with *testing.T passed in
 	func(t *testing.T) {
-		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestAnonymousFunctionCall\""
 	}(t)
 }
 
 // TestDeferWithMultipleStatements shows defer with complex function
 func TestDeferWithMultipleStatements(t *testing.T) {
-	defer func() { // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+	defer func() { // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferWithMultipleStatements\""
 		cleanup()
 		// multiple statements
 		if someCondition() {
@@ -197,22 +268,22 @@ func TestWithWrongParameterType(s string) {
 }
 
 // TestMixedDeferAndCleanup shows mixed usage
-func TestMixedDeferAndCleanup(t *testing.T) {
-	defer cleanup()    // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+func TestMixedDeferAndCleanup(t *testing.T) { // want "defer and t.Cleanup are mixed; deferred calls run when the function returns, before the functions registered with t.Cleanup, which run after the test and its subtests have completed, in test function \"TestMixedDeferAndCleanup\""
+	defer cleanup()    // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestMixedDeferAndCleanup\""
 	t.Cleanup(cleanup) // No warning - correct approach
-	defer func() {}()  // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+	defer func() {}()  // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestMixedDeferAndCleanup\""
 }
 
 // TestDeferOfNamedFunction shows defer of different types of functions
 func TestDeferOfNamedFunction(t *testing.T) {
-	defer cleanup()             // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
-	defer helperWithDefer()     // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
-	defer t.Log("deferred log") // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+	defer cleanup()             // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferOfNamedFunction\""
+	defer helperWithDefer()     // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferOfNamedFunction\""
+	defer t.Log("deferred log") // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferOfNamedFunction\""
 }
 
 // TestEmptyDefer shows defer with empty function
 func TestEmptyDefer(t *testing.T) {
-	defer func() {}() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+	defer func() {}() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestEmptyDefer\""
 }
 
 // TestDeferInGoroutine shows defer inside goroutine
@@ -225,7 +296,7 @@ func TestDeferInGoroutine(t *testing.T) {
 // TestDeferWithGoroutineAndTestingT shows goroutine with *testing.T parameter
 func TestDeferWithGoroutineAndTestingT(t *testing.T) {
 	go func(t *testing.T) {
-		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+		defer cleanup() // No warning - t.Fatal must not be called from a goroutine
 	}(t)
 }
 
@@ -241,5 +312,38 @@ func Test(t *testing.T) {
 
 // TestA is the shortest valid test function name
 func TestA(t *testing.T) {
-	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestA\""
+}
+
+// TesticleHelper is not a test function - "Test" is followed by a lowercase letter
+func TesticleHelper(t *testing.T) {
+	defer cleanup() // No warning - not a test function
+}
+
+// Test_underscore is a test function - "_" is not a lowercase letter
+func Test_underscore(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"Test_underscore\""
+}
+
+// Benchmarking is not a benchmark function
+func Benchmarking(b *testing.B) {
+	defer cleanup() // No warning - not a benchmark function
+}
+
+// Fuzzy is not a fuzz target
+func Fuzzy(f *testing.F) {
+	defer cleanup() // No warning - not a fuzz target
+}
+
+// TestDeferUnderShortGuard shows defers only run in long mode are still reported
+// without -ignore-under-short-guard
+func TestDeferUnderShortGuard(t *testing.T) {
+	if !testing.Short() {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferUnderShortGuard\""
+	}
+
+	if testing.Short() {
+		t.Skip("long test")
+	}
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferUnderShortGuard\""
 }
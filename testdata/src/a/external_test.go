@@ -0,0 +1,16 @@
+package a_test
+
+import (
+	"testing"
+
+	"a"
+)
+
+// TestExternalPackage shows defers in external test packages are reported
+func TestExternalPackage(t *testing.T) {
+	defer a.NotATestFunction(t) // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestExternalPackage\""
+
+	t.Run("sub", func(t *testing.T) {
+		defer a.NotATestFunction(t) // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestExternalPackage\""
+	})
+}
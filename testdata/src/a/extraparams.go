@@ -0,0 +1,8 @@
+package a
+
+import "testing"
+
+// TestExtraParams is a parameterized helper invoked by a driver, checked like a test by default
+func TestExtraParams(t *testing.T, extra int) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestExtraParams\""
+}
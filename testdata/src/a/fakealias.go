@@ -0,0 +1,15 @@
+package a
+
+import (
+	testing "a/testing2"
+)
+
+// TestFakeTestingAlias uses an unrelated package aliased as "testing"
+func TestFakeTestingAlias(t *testing.T) {
+	defer cleanup() // No warning - not the real testing package
+}
+
+// BenchmarkFakeTestingAlias uses an unrelated package aliased as "testing"
+func BenchmarkFakeTestingAlias(b *testing.B) {
+	defer cleanup() // No warning - not the real testing package
+}
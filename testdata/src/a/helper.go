@@ -0,0 +1,38 @@
+package a
+
+import "testing"
+
+// setupWithDefer is a helper receiving *testing.T
+func setupWithDefer(t *testing.T) {
+	t.Helper()
+	defer cleanup() // No warning - helpers are only checked with -check-helpers
+}
+
+// checkTB is a helper receiving testing.TB
+func checkTB(tb testing.TB) {
+	defer cleanup() // No warning - helpers are only checked with -check-helpers
+}
+
+// TestTB shows a subtest closure receiving testing.TB is checked
+func TestTB(t *testing.T) {
+	func(tb testing.TB) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestTB\""
+	}(t)
+}
+
+type fixture struct {
+	t *testing.T
+}
+
+// setup is a fixture method storing *testing.T in a field
+func (f *fixture) setup() {
+	defer cleanup() // No warning - fixture methods are only checked with -check-t-fields
+}
+
+// Runner is run by a framework that calls Run as the body of each test
+type Runner struct{}
+
+// Run is only checked when listed in -method-names
+func (r Runner) Run(t *testing.T) {
+	defer cleanup()
+}
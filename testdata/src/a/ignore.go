@@ -0,0 +1,28 @@
+package a
+
+import "testing"
+
+var global = 1
+
+// TestIgnoreDirective shows defers suppressed by an ignore directive
+func TestIgnoreDirective(t *testing.T) {
+	old := global
+	defer func() { global = old }() //nodefertest:ignore restoring a global, t.Fatal is never called
+
+	//nodefertest:ignore
+	defer cleanup()
+
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestIgnoreDirective\""
+
+	t.Run("subtest", func(t *testing.T) {
+		defer cleanup() //nodefertest:ignore
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestIgnoreDirective\""
+	})
+}
+
+// TestIgnoreDirectiveTooFar shows the directive only covers the next line
+func TestIgnoreDirectiveTooFar(t *testing.T) {
+	//nodefertest:ignore
+
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestIgnoreDirectiveTooFar\""
+}
@@ -0,0 +1,11 @@
+// Package localt declares its own T, unrelated to testing.T.
+package localt
+
+type T struct{}
+
+// TestLocalT takes a local type named T
+func TestLocalT(t *T) {
+	defer cleanup() // No warning - *T is not testing.T
+}
+
+func cleanup() {}
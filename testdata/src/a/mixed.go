@@ -0,0 +1,74 @@
+package a
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestMixedOnce shows the mixed usage is noted once however many defers and cleanups there are
+func TestMixedOnce(t *testing.T) { // want "defer and t.Cleanup are mixed; deferred calls run when the function returns, before the functions registered with t.Cleanup, which run after the test and its subtests have completed, in test function \"TestMixedOnce\""
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestMixedOnce\""
+	t.Cleanup(cleanup)
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestMixedOnce\""
+	t.Cleanup(cleanup)
+}
+
+// TestMixedInSubtest shows each function is considered on its own
+func TestMixedInSubtest(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestMixedInSubtest\""
+
+	t.Run("sub", func(t *testing.T) { // want "defer and t.Cleanup are mixed; deferred calls run when the function returns, before the functions registered with t.Cleanup, which run after the test and its subtests have completed, in test function \"TestMixedInSubtest\""
+		t.Cleanup(cleanup)
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestMixedInSubtest\""
+	})
+}
+
+// TestMixedIgnored shows ignored defers are not counted
+func TestMixedIgnored(t *testing.T) {
+	t.Cleanup(cleanup)
+	defer cleanup() //nodefertest:ignore
+}
+
+// TestMixedUnlock shows allowed unlocks are not counted
+func TestMixedUnlock(t *testing.T) {
+	var mu sync.Mutex
+	t.Cleanup(cleanup)
+	mu.Lock()
+	defer mu.Unlock()
+}
+
+// TestMixedRecover shows allowed recover-only defers are not counted
+func TestMixedRecover(t *testing.T) {
+	t.Cleanup(cleanup)
+	defer func() {
+		recover()
+	}()
+}
+
+// TestMixedSuppressed shows an ignore directive on the line of the function suppresses the note
+func TestMixedSuppressed(t *testing.T) { //nodefertest:ignore
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestMixedSuppressed\""
+	t.Cleanup(cleanup)
+
+	t.Run("sub", func(t *testing.T) { //nodefertest:ignore
+		t.Cleanup(cleanup)
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestMixedSuppressed\""
+	})
+}
+
+// TestMixedConditionalCleanup shows a conditional t.Cleanup counts just the same
+func TestMixedConditionalCleanup(t *testing.T) { // want "defer and t.Cleanup are mixed; deferred calls run when the function returns, before the functions registered with t.Cleanup, which run after the test and its subtests have completed, in test function \"TestMixedConditionalCleanup\""
+	if testing.Verbose() {
+		t.Cleanup(cleanup)
+	}
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestMixedConditionalCleanup\""
+}
+
+// TestCleanupInClosure shows t.Cleanup in a nested closure is not counted for the function
+func TestCleanupInClosure(t *testing.T) {
+	register := func() {
+		t.Cleanup(cleanup)
+	}
+	register()
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestCleanupInClosure\""
+}
@@ -0,0 +1,22 @@
+package a
+
+import "testing"
+
+// TestNamedSubtest shows a subtest run with a package-level function
+func TestNamedSubtest(t *testing.T) {
+	t.Run("named", tableCase)
+	t.Run("parenthesized", (otherCase))
+}
+
+func tableCase(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"tableCase\""
+}
+
+func otherCase(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"otherCase\""
+}
+
+// notRunAsSubtest is never passed to t.Run, so it is a plain helper
+func notRunAsSubtest(t *testing.T) {
+	defer cleanup()
+}
@@ -0,0 +1,38 @@
+package a
+
+import "testing"
+
+// Test_Something is a test function, named with an underscore as go test allows
+func Test_Something(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"Test_Something\""
+}
+
+// Benchmark_Something is a benchmark named the same way
+func Benchmark_Something(b *testing.B) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"Benchmark_Something\""
+}
+
+// Test_ is a test function, as go test only requires the rune after the prefix to not be lowercase
+func Test_(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"Test_\""
+}
+
+// testHelper is unexported, so it is a helper rather than a test
+func testHelper(t *testing.T) {
+	defer cleanup()
+}
+
+// Testsomething has a lowercase rune after the prefix, so go test does not run it
+func Testsomething(t *testing.T) {
+	defer cleanup()
+}
+
+// TestÉcole starts with an uppercase non-ASCII letter, which go test accepts
+func TestÉcole(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestÉcole\""
+}
+
+// Testéchec starts with a lowercase non-ASCII letter, which go test rejects
+func Testéchec(t *testing.T) {
+	defer cleanup()
+}
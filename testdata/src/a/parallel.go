@@ -0,0 +1,76 @@
+package a
+
+import "testing"
+
+type resource struct{}
+
+func (r *resource) Close() {}
+
+// TestDeferWithParallelSubtests shows defer closing a resource used by parallel subtests
+func TestDeferWithParallelSubtests(t *testing.T) {
+	r := &resource{}
+	defer r.Close() // want "use t.Cleanup\\(\\) instead of defer in test functions with parallel subtests; deferred calls run when the function returns, before the subtests calling t.Parallel\\(\\) have completed in test function \"TestDeferWithParallelSubtests\""
+
+	for _, name := range []string{"a", "b"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_ = r
+		})
+	}
+}
+
+// TestDeferWithSerialSubtests shows subtests without t.Parallel get the usual message
+func TestDeferWithSerialSubtests(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferWithSerialSubtests\""
+
+	t.Run("serial", func(t *testing.T) {
+		func() {
+			t.Parallel() // Not called directly by the subtest
+		}()
+	})
+}
+
+// TestDeferInParallelSubtest shows a defer inside the parallel subtest itself
+func TestDeferInParallelSubtest(t *testing.T) {
+	t.Run("parallel", func(t *testing.T) {
+		t.Parallel()
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferInParallelSubtest\""
+	})
+}
+
+// TestDeferBeforeParallel shows a defer registered before the subtest calls t.Parallel
+func TestDeferBeforeParallel(t *testing.T) {
+	t.Run("parallel", func(t *testing.T) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer before t.Parallel\\(\\); t.Parallel\\(\\) pauses the test until its parent test function has returned, so resources the deferred call releases may be shared with tests that outlive the pause, and the call is skipped after t.Fatal/t.FailNow in test function \"TestDeferBeforeParallel\""
+		t.Parallel()
+	})
+}
+
+// TestDeferBeforeOwnParallel shows a top-level test deferring before its own t.Parallel
+func TestDeferBeforeOwnParallel(t *testing.T) {
+	r := &resource{}
+	defer r.Close() // want "use t.Cleanup\\(\\) instead of defer before t.Parallel\\(\\); .* in test function \"TestDeferBeforeOwnParallel\""
+	t.Parallel()
+	_ = r
+}
+
+// TestDeferWithNamedParallelSubtest shows a subtest function run by name that calls t.Parallel
+func TestDeferWithNamedParallelSubtest(t *testing.T) {
+	r := &resource{}
+	defer r.Close() // want "use t.Cleanup\\(\\) instead of defer in test functions with parallel subtests; deferred calls run when the function returns, before the subtests calling t.Parallel\\(\\) have completed in test function \"TestDeferWithNamedParallelSubtest\""
+
+	t.Run("parallel", parallelCase)
+}
+
+// TestDeferWithNamedSerialSubtest shows a subtest function run by name without t.Parallel
+func TestDeferWithNamedSerialSubtest(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferWithNamedSerialSubtest\""
+
+	t.Run("serial", serialCase)
+}
+
+func parallelCase(t *testing.T) {
+	t.Parallel()
+}
+
+func serialCase(t *testing.T) {}
@@ -0,0 +1,36 @@
+package a
+
+import "testing"
+
+// setupTest runs the setup right away and returns its teardown
+func setupTest(t *testing.T) func() {
+	return func() {}
+}
+
+// TestDeferredSetup shows the teardown returned by a setup call being deferred
+func TestDeferredSetup(t *testing.T) {
+	defer setupTest(t)()   // want "use t.Cleanup\\(setupTest\\(t\\)\\) instead of deferring the teardown returned by a setup call, or better, register the teardown with t.Cleanup inside the setup function; a deferred teardown is skipped after t.Fatal/t.FailNow in test function \"TestDeferredSetup\""
+	defer (setupTest(t))() // want "use t.Cleanup\\(setupTest\\(t\\)\\) instead of deferring the teardown returned by a setup call, or better, register the teardown with t.Cleanup inside the setup function; a deferred teardown is skipped after t.Fatal/t.FailNow in test function \"TestDeferredSetup\""
+
+}
+
+// setupDB returns a resource along with its teardown
+func setupDB(t *testing.T) (string, func()) {
+	return "db", func() {}
+}
+
+// TestBoundTeardown shows the teardown returned by a setup call assigned to a variable
+func TestBoundTeardown(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown() // want "use t.Cleanup\\(teardown\\) instead of deferring teardown, the teardown returned by the setup call setupTest, or better, register the teardown with t.Cleanup inside the setup function; a deferred teardown is skipped after t.Fatal/t.FailNow in test function \"TestBoundTeardown\""
+
+	_, closeDB := setupDB(t)
+	defer closeDB() // want "use t.Cleanup\\(closeDB\\) instead of deferring closeDB, the teardown returned by the setup call setupDB, or better, register the teardown with t.Cleanup inside the setup function; a deferred teardown is skipped after t.Fatal/t.FailNow in test function \"TestBoundTeardown\""
+
+	var stop func() = setupTest(t)
+	defer stop() // want "use t.Cleanup\\(stop\\) instead of deferring stop, the teardown returned by the setup call setupTest, or better, register the teardown with t.Cleanup inside the setup function; a deferred teardown is skipped after t.Fatal/t.FailNow in test function \"TestBoundTeardown\""
+
+	// Not a setup call, since it does not receive t
+	done := func() {}
+	defer done() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestBoundTeardown\""
+}
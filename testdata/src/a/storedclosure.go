@@ -0,0 +1,87 @@
+package a
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestStoredSubtestClosure shows a subtest function stored in a variable before t.Run
+func TestStoredSubtestClosure(t *testing.T) {
+	fn := func(t *testing.T) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestStoredSubtestClosure\""
+	}
+	t.Run("stored", fn)
+
+	var declared func(*testing.T)
+	declared = func(t *testing.T) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestStoredSubtestClosure\""
+	}
+	t.Run("declared", declared)
+}
+
+// TestStoredSubtestTable shows subtest functions stored in a map
+func TestStoredSubtestTable(t *testing.T) {
+	tests := map[string]func(t *testing.T){
+		"first": func(t *testing.T) {
+			defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestStoredSubtestTable\""
+		},
+	}
+	for name, fn := range tests {
+		t.Run(name, fn)
+	}
+}
+
+// fieldFixture stores the subtest functions of TestStoredFieldClosure
+type fieldFixture struct {
+	run   func(t *testing.T)
+	steps map[string]func(*testing.T)
+}
+
+// TestStoredFieldClosure shows subtest functions assigned to struct fields and
+// elements, which are selectors and index expressions rather than identifiers
+func TestStoredFieldClosure(t *testing.T) {
+	f := &fieldFixture{steps: make(map[string]func(*testing.T))}
+	f.run = func(t *testing.T) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestStoredFieldClosure\""
+	}
+	f.steps["step"] = func(t *testing.T) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestStoredFieldClosure\""
+	}
+	t.Run("run", f.run)
+	t.Run("step", f.steps["step"])
+}
+
+// TestStoredSubtestReused shows a subtest function run for every case is reported once,
+// since the literal appears once in the source
+func TestStoredSubtestReused(t *testing.T) {
+	run := func(t *testing.T) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestStoredSubtestReused\""
+	}
+	cases := []struct{ name string }{{"first"}, {"second"}, {"third"}}
+	for _, c := range cases {
+		t.Run(c.name, run)
+	}
+	t.Run("again", run)
+}
+
+// BenchmarkStoredSubBenchmarkReused shows a sub-benchmark function run for every size
+// is reported once, like a reused subtest function
+func BenchmarkStoredSubBenchmarkReused(b *testing.B) {
+	bench := func(b *testing.B) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"BenchmarkStoredSubBenchmarkReused\""
+	}
+	for _, size := range []int{1, 10, 100} {
+		b.Run(fmt.Sprint(size), bench)
+	}
+	b.Run("again", bench)
+}
+
+// TestStoredTBClosure shows a testing.TB closure shared by a subtest and a sub-benchmark
+// is reported once
+func TestStoredTBClosure(t *testing.T) {
+	check := func(tb testing.TB) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestStoredTBClosure\""
+	}
+	t.Run("test", func(t *testing.T) { check(t) })
+	testing.Benchmark(func(b *testing.B) { check(b) })
+}
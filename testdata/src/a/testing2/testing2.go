@@ -0,0 +1,8 @@
+// Package testing2 is an unrelated package that mimics the shape of testing.
+package testing2
+
+type T struct{}
+
+type B struct{}
+
+type F struct{}
@@ -0,0 +1,16 @@
+package a
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain is not a test function, so defer is fine here
+func TestMain(m *testing.M) {
+	defer cleanup() // No warning - TestMain is excluded
+
+	code := m.Run()
+	if code != 0 {
+		os.Exit(code)
+	}
+}
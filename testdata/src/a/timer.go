@@ -0,0 +1,24 @@
+package a
+
+import "testing"
+
+// BenchmarkTimerControls shows deferred timer controls are allowed
+func BenchmarkTimerControls(b *testing.B) {
+	defer b.StopTimer()
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"BenchmarkTimerControls\""
+
+	b.Run("sub", func(sb *testing.B) {
+		defer sb.StartTimer()
+		defer sb.ResetTimer()
+	})
+}
+
+// TestTimerLookalike shows only the methods of testing types are allowed
+func TestTimerLookalike(t *testing.T) {
+	var w stopwatch
+	defer w.StopTimer() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestTimerLookalike\""
+}
+
+type stopwatch struct{}
+
+func (stopwatch) StopTimer() {}
@@ -0,0 +1,11 @@
+package a
+
+import "testing"
+
+// AliasT is a re-exported alias of testing.T
+type AliasT = testing.T
+
+// TestTypeAlias shows defer in a test whose parameter uses a type alias
+func TestTypeAlias(t *AliasT) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestTypeAlias\""
+}
@@ -0,0 +1,45 @@
+package a
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestDeferUnlock shows deferred mutex unlocks are allowed
+func TestDeferUnlock(t *testing.T) {
+	var mu sync.Mutex
+	mu.Lock()
+	defer mu.Unlock() // No warning - unlocking a sync.Mutex
+
+	var rw sync.RWMutex
+	rw.RLock()
+	defer rw.RUnlock() // No warning - unlocking a sync.RWMutex
+	rw.Lock()
+	defer rw.Unlock() // No warning - unlocking a sync.RWMutex
+
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferUnlock\""
+}
+
+type guarded struct {
+	sync.Mutex
+	mu sync.RWMutex
+}
+
+// TestDeferUnlockField shows unlocks of embedded and field mutexes are allowed
+func TestDeferUnlockField(t *testing.T) {
+	var g guarded
+	g.Lock()
+	defer g.Unlock() // No warning - promoted sync.Mutex.Unlock
+	g.mu.Lock()
+	defer g.mu.Unlock() // No warning - unlocking a sync.RWMutex field
+}
+
+type fakeLocker struct{}
+
+func (fakeLocker) Unlock() {}
+
+// TestDeferFakeUnlock shows Unlock on an unrelated type is still flagged
+func TestDeferFakeUnlock(t *testing.T) {
+	var l fakeLocker
+	defer l.Unlock() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferFakeUnlock\""
+}
@@ -0,0 +1,44 @@
+package allowcalls
+
+import (
+	"os"
+	"testing"
+)
+
+// TestAllowCalls shows allowlisted deferred calls are not flagged
+func TestAllowCalls(t *testing.T) {
+	f, err := os.CreateTemp("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove(f.Name()) // No warning - os.Remove is allowed
+
+	defer func() { // No warning - the only call is allowed
+		os.RemoveAll(f.Name())
+	}()
+
+	defer f.Close() // No warning - (*os.File).Close is allowed
+
+	defer os.Chdir("..") // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestAllowCalls\""
+
+	defer func() { // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestAllowCalls\""
+		os.Remove(f.Name())
+		cleanup()
+	}()
+}
+
+// TestAllowSelector shows allowlisted selectors are not flagged
+func TestAllowSelector(t *testing.T) {
+	var s server
+	defer s.Shutdown() // No warning - s.Shutdown is allowed
+
+	var other server
+	defer other.Shutdown() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestAllowSelector\""
+}
+
+type server struct{}
+
+func (server) Shutdown() {}
+
+func cleanup() {}
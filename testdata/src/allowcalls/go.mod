@@ -0,0 +1,3 @@
+module allowcalls
+
+go 1.25.1
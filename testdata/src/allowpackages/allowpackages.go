@@ -0,0 +1,21 @@
+package allowpackages
+
+import (
+	"testing"
+
+	"allowpackages/testhelpers"
+)
+
+func cleanup() {}
+
+// TestAllowPackages shows functions and methods of allowed packages may be deferred
+func TestAllowPackages(t *testing.T) {
+	defer testhelpers.Teardown()
+
+	var env testhelpers.Env
+	defer env.Restore()
+
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestAllowPackages\""
+	// Only the deferred function itself is resolved, not the calls in a literal
+	defer func() { testhelpers.Teardown() }() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestAllowPackages\""
+}
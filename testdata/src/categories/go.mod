@@ -0,0 +1,3 @@
+module categories
+
+go 1.25.1
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func cleanup() {}
+
+// TestLegacy is listed in exclude-functions of the configuration file
+func TestLegacy(t *testing.T) {
+	defer cleanup()
+
+	t.Run("sub", func(t *testing.T) {
+		defer cleanup()
+	})
+}
+
+// TestConfigured shows the allowed calls of the configuration file
+func TestConfigured(t *testing.T) {
+	defer os.RemoveAll("dir")
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestConfigured\""
+}
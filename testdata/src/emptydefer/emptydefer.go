@@ -0,0 +1,18 @@
+package emptydefer
+
+import "testing"
+
+// TestEmptyDefer shows an empty deferred function literal is allowed
+func TestEmptyDefer(t *testing.T) {
+	defer func() {}()
+	defer (func() {})()
+	defer func() {
+		// Nothing to clean up yet
+	}()
+}
+
+// TestNonEmptyDefer shows deferred function literals that do something are still reported
+func TestNonEmptyDefer(t *testing.T) {
+	defer func() { t.Log("done") }() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestNonEmptyDefer\""
+	defer func(string) {}("value")   // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestNonEmptyDefer\""
+}
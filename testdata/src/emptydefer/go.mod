@@ -0,0 +1,3 @@
+module emptydefer
+
+go 1.25.1
@@ -0,0 +1,57 @@
+package errgrouptest
+
+import (
+	"testing"
+
+	"golang.org/x/sync/errgroup"
+)
+
+func cleanup() {}
+
+// TestGroupGo shows defers in functions run by an errgroup, where t.Fatal cannot be
+// called, so defer is the right way to clean up
+func TestGroupGo(t *testing.T) {
+	var g errgroup.Group
+	g.Go(func() error {
+		defer cleanup() // No warning - runs in a goroutine of the group
+		t.Log("working")
+		return nil
+	})
+	g.TryGo(func() error {
+		defer cleanup() // No warning - runs in a goroutine of the group
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestGroupGoFatal shows a t.Fatal in a function run by an errgroup, which only stops
+// that goroutine, so the defer of the test is not skipped by it; it is only reported
+// without -require-fatal
+func TestGroupGoFatal(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestGroupGoFatal\""
+
+	var g errgroup.Group
+	g.Go(func() error {
+		t.Fatal("misused")
+		return nil
+	})
+	go func() {
+		t.Fatal("misused")
+	}()
+	_ = g.Wait()
+}
+
+// TestGroupGoAndFatal shows the t.Fatal of the test itself still counts
+func TestGroupGoAndFatal(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestGroupGoAndFatal\""
+
+	var g errgroup.Group
+	g.Go(func() error {
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
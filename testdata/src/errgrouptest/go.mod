@@ -0,0 +1,7 @@
+module errgrouptest
+
+go 1.25.1
+
+require golang.org/x/sync v0.17.0
+
+replace golang.org/x/sync => ../xsync
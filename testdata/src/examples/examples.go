@@ -0,0 +1,48 @@
+package examples
+
+import (
+	"fmt"
+	"testing"
+)
+
+// ExampleDo shows defer in an example function
+func ExampleDo() {
+	defer cleanup() // want "avoid defer in example functions; examples run as part of the test binary and deferred calls are skipped if the example exits early in example function \"ExampleDo\""
+
+	fmt.Println("done")
+	// Output: done
+}
+
+// Example is the package example
+func Example() {
+	defer cleanup() // want "avoid defer in example functions; examples run as part of the test binary and deferred calls are skipped if the example exits early in example function \"Example\""
+}
+
+// Example_suffix shows a suffixed package example
+func Example_suffix() {
+	defer cleanup() //nodefertest:ignore
+}
+
+// ExampleClosure shows function literals are not checked
+func ExampleClosure() {
+	func() {
+		defer cleanup() // No warning - runs in its own frame
+	}()
+}
+
+// Exampled is not an example function
+func Exampled() {
+	defer cleanup() // No warning - not an example function
+}
+
+// ExampleWithParam is not an example function since it takes parameters
+func ExampleWithParam(s string) {
+	defer cleanup() // No warning - not an example function
+}
+
+// TestStillChecked shows tests are checked as usual
+func TestStillChecked(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestStillChecked\""
+}
+
+func cleanup() {}
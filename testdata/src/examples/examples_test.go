@@ -0,0 +1,16 @@
+package examples
+
+import "fmt"
+
+// ExampleInTestFile shows an example in a _test.go file, where go test runs it
+func ExampleInTestFile() {
+	defer cleanup() // want "avoid defer in example functions; examples run as part of the test binary and deferred calls are skipped if the example exits early in example function \"ExampleInTestFile\""
+
+	fmt.Println("done")
+	// Output: done
+}
+
+// ExampleWithTestPrefix_test shows examples are told apart from tests by their name alone
+func ExampleWithTestPrefix_test() {
+	defer cleanup() // want "avoid defer in example functions; examples run as part of the test binary and deferred calls are skipped if the example exits early in example function \"ExampleWithTestPrefix_test\""
+}
@@ -0,0 +1,3 @@
+module exclude
+
+go 1.25.1
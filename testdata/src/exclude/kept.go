@@ -0,0 +1,10 @@
+package exclude
+
+import "testing"
+
+// TestKept is in a file that is analyzed
+func TestKept(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestKept\""
+}
+
+func cleanup() {}
@@ -0,0 +1,8 @@
+package exclude
+
+import "testing"
+
+// TestGenerated is in a file excluded by -exclude-files
+func TestGenerated(t *testing.T) {
+	defer cleanup() // No warning - file is excluded
+}
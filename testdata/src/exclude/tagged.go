@@ -0,0 +1,10 @@
+//go:build !nodefertest_ignore
+
+package exclude
+
+import "testing"
+
+// TestTagged is in a file excluded by its build constraint
+func TestTagged(t *testing.T) {
+	defer cleanup() // No warning - file is excluded
+}
@@ -0,0 +1,10 @@
+package excludedirs
+
+import "testing"
+
+// TestOwned is in a file outside of the excluded directories
+func TestOwned(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestOwned\""
+}
+
+func cleanup() {}
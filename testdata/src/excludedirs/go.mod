@@ -0,0 +1,3 @@
+module excludedirs
+
+go 1.25.1
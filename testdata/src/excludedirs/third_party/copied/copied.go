@@ -0,0 +1,10 @@
+package copied
+
+import "testing"
+
+// TestCopied is in third_party/, which is skipped with -exclude-dirs=third_party/
+func TestCopied(t *testing.T) {
+	defer cleanup() // No warning - in an excluded directory
+}
+
+func cleanup() {}
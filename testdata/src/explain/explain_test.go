@@ -0,0 +1,19 @@
+package explain
+
+import "testing"
+
+func TestChecked(t *testing.T) {
+	defer t.Log("done") // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow"
+}
+
+func helper(t *testing.T) {
+	defer t.Log("done")
+}
+
+func TestExcluded(t *testing.T) {
+	defer t.Log("done")
+}
+
+func TestMain(m *testing.M) {
+	m.Run()
+}
@@ -0,0 +1,3 @@
+module explain
+
+go 1.25.1
@@ -0,0 +1,8 @@
+package lib
+
+import "testing"
+
+// Open is explained, though the package has no tests, unless -only-test-files is set
+func Open(t *testing.T) {
+	defer t.Log("done")
+}
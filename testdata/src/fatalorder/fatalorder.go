@@ -0,0 +1,76 @@
+package fatalorder
+
+import "testing"
+
+type resource struct{}
+
+func (r *resource) Close() {}
+
+func open() (*resource, error) { return &resource{}, nil }
+
+func fail(t *testing.T) { t.FailNow() } // want fail:"stoppingHelper"
+
+// TestDeferAfterLastFatal shows a defer after the last call that may stop the test
+func TestDeferAfterLastFatal(t *testing.T) {
+	r, err := open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+}
+
+// TestDeferBeforeFatal shows a defer followed by a call that may stop the test
+func TestDeferBeforeFatal(t *testing.T) {
+	r, err := open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow; t.Fatalf on line 31 may stop the test after the defer in test function \"TestDeferBeforeFatal\""
+
+	if r == nil {
+		t.Fatalf("got no resource")
+	}
+}
+
+// TestDeferBeforeHelper shows a defer followed by a helper that may call t.Fatal
+func TestDeferBeforeHelper(t *testing.T) {
+	r, _ := open()
+	defer r.Close() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow; check on line 39 may stop the test after the defer in test function \"TestDeferBeforeHelper\""
+	check(t)
+}
+
+// TestDeferInLoopAfterFatal shows a defer in a loop, where the t.Fatal of the next
+// iteration follows the defer of this one
+func TestDeferInLoopAfterFatal(t *testing.T) {
+	for range 3 {
+		r, err := open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close() // want "use t.Cleanup\\(\\) instead of defer in a loop in test functions; deferred calls accumulate until the test function returns instead of running per iteration, and are skipped after t.Fatal/t.FailNow; t.Fatal on line 48 may stop the test after the defer in test function \"TestDeferInLoopAfterFatal\""
+	}
+}
+
+// TestDeferWithoutFatal shows a defer with nothing to stop the test
+func TestDeferWithoutFatal(t *testing.T) {
+	defer func() {}()
+}
+
+// TestFatalAfterDefers shows t.Fatal in a cleanup or a deferred call, which runs after the defer
+func TestFatalAfterDefers(t *testing.T) { // want "defer and t.Cleanup are mixed; deferred calls run when the function returns, before the functions registered with t.Cleanup, which run after the test and its subtests have completed, in test function \"TestFatalAfterDefers\""
+	r, _ := open()
+	defer r.Close()
+	t.Cleanup(func() {
+		t.Fatal("in a cleanup") // want "avoid t.Fatal/t.FailNow in functions registered with t.Cleanup; it skips the rest of the cleanup function, so report with t.Error and continue the cleanup, in test function \"TestFatalAfterDefers\""
+	})
+	defer func() {
+		t.Fatal("in a deferred call")
+	}()
+}
+
+// check may stop the test through fail, declared before it
+func check(t *testing.T) { // want check:"stoppingHelper"
+	if t.Failed() {
+		fail(t)
+	}
+}
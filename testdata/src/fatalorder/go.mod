@@ -0,0 +1,3 @@
+module fatalorder
+
+go 1.25.1
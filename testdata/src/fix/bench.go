@@ -0,0 +1,15 @@
+package fix
+
+import "testing"
+
+// BenchmarkSub shows the sub-benchmark's parameter is used
+func BenchmarkSub(b *testing.B) {
+	b.Run("sub", func(sb *testing.B) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"BenchmarkSub\""
+	})
+}
+
+// BenchmarkTParamName shows the parameter name is used whatever its type
+func BenchmarkTParamName(t *testing.B) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"BenchmarkTParamName\""
+}
@@ -0,0 +1,31 @@
+package fix
+
+import "testing"
+
+// cleanupFunc is a named func type that deferred calls are converted to
+type cleanupFunc func()
+
+// closeFunc is a named func type returning an error
+type closeFunc func() error
+
+func closeAll() error { return nil }
+
+// TestDeferParenFunc shows a parenthesized deferred function
+func TestDeferParenFunc(t *testing.T) {
+	defer (cleanup)() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferParenFunc\""
+}
+
+// TestDeferConvertedFunc shows a deferred call of a func converted to a named type
+func TestDeferConvertedFunc(t *testing.T) {
+	defer cleanupFunc(cleanup)() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferConvertedFunc\""
+}
+
+// TestDeferParenConvertedFunc shows a deferred call of a func converted to a parenthesized type
+func TestDeferParenConvertedFunc(t *testing.T) {
+	defer (func())(cleanup)() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferParenConvertedFunc\""
+}
+
+// TestDeferConvertedFuncResult shows a deferred call of a converted func with a result
+func TestDeferConvertedFuncResult(t *testing.T) {
+	defer closeFunc(closeAll)() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferConvertedFuncResult\""
+}
@@ -0,0 +1,11 @@
+package fix
+
+import "testing"
+
+// TestNestedBenchmark shows the innermost parameter is used across testing types
+func TestNestedBenchmark(t *testing.T) {
+	testing.Benchmark(func(b *testing.B) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestNestedBenchmark\""
+	})
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestNestedBenchmark\""
+}
@@ -0,0 +1,60 @@
+package fix
+
+import (
+	"os"
+	"testing"
+)
+
+// TestNamedFunc shows defer of a named function being rewritten
+func TestNamedFunc(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestNamedFunc\""
+}
+
+// TestFuncLit shows defer of a function literal being rewritten
+func TestFuncLit(t *testing.T) {
+	defer func() { // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestFuncLit\""
+		cleanup()
+	}()
+}
+
+// TestOtherParamName shows the actual parameter name is used
+func TestOtherParamName(tt *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestOtherParamName\""
+}
+
+// TestSubtest shows the subtest's parameter name is used
+func TestSubtest(t *testing.T) {
+	t.Run("sub", func(st *testing.T) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestSubtest\""
+	})
+}
+
+// BenchmarkNamedFunc shows b.Cleanup is used in benchmarks
+func BenchmarkNamedFunc(b *testing.B) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"BenchmarkNamedFunc\""
+}
+
+// TestWithArgs shows calls with arguments are wrapped in a closure
+func TestWithArgs(t *testing.T) {
+	defer os.Remove("file")          // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestWithArgs\""
+	defer func(s string) {}("value") // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestWithArgs\""
+}
+
+// TestWithResult shows functions returning values are wrapped in a closure
+func TestWithResult(t *testing.T) {
+	defer cleanupErr() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestWithResult\""
+}
+
+// TestUnnamedParam shows no fix is offered without a parameter name
+func TestUnnamedParam(_ *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestUnnamedParam\""
+}
+
+func cleanup() {}
+
+func cleanupErr() error { return nil }
+
+// TestTBParam shows the testing.TB parameter name is used
+func TestTBParam(tb testing.TB) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestTBParam\""
+}
@@ -0,0 +1,11 @@
+package fix
+
+import "testing"
+
+// FuzzSetup shows f.Cleanup is used in the fuzz function itself
+func FuzzSetup(f *testing.F) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"FuzzSetup\""
+	f.Fuzz(func(t *testing.T, s string) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"FuzzSetup\""
+	})
+}
@@ -0,0 +1,43 @@
+package fix
+
+import (
+	"os"
+	"testing"
+)
+
+type server struct{}
+
+func (s *server) Close() {}
+
+func (s *server) Shutdown(code int) {}
+
+// TestMethodValue shows a method value binds its receiver right away, like defer
+func TestMethodValue(t *testing.T) {
+	srv := &server{}
+	defer srv.Close() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestMethodValue\""
+
+	f, _ := os.Open("file")
+	defer f.Close() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestMethodValue\""
+}
+
+// TestMethodExpr shows method expressions take the receiver as an argument
+func TestMethodExpr(t *testing.T) {
+	srv := &server{}
+	defer (*server).Close(srv)       // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestMethodExpr\""
+	defer (*server).Shutdown(srv, 1) // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestMethodExpr\""
+}
+
+// TestPackageFunc shows package-qualified functions are passed as is
+func TestPackageFunc(t *testing.T) {
+	defer os.Clearenv() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestPackageFunc\""
+}
+
+type stopper struct {
+	stop func()
+}
+
+// TestFieldValue shows fields of function type are passed as is
+func TestFieldValue(t *testing.T) {
+	s := stopper{stop: func() {}}
+	defer s.stop() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestFieldValue\""
+}
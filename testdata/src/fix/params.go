@@ -0,0 +1,30 @@
+package fix
+
+import "testing"
+
+// TestMultipleParams shows the fix uses the first named testing parameter
+func TestMultipleParams(t *testing.T, u *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestMultipleParams\""
+}
+
+// TestGroupedParams shows the first name of a grouped parameter is used
+func TestGroupedParams(t, u *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestGroupedParams\""
+}
+
+// TestBlankFirstParam shows a blank parameter is skipped for the next one
+func TestBlankFirstParam(_ *testing.T, u *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestBlankFirstParam\""
+}
+
+// TestVariadicParams shows a variadic ...*testing.T parameter is a slice rather
+// than a testing parameter, so closures taking only one are not checked
+func TestVariadicParams(t *testing.T) {
+	run := func(ts ...*testing.T) {
+		defer cleanup()
+	}
+	run(t)
+	func(u *testing.T, ts ...*testing.T) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestVariadicParams\""
+	}(t)
+}
@@ -0,0 +1,73 @@
+package fix
+
+import (
+	"os"
+	"testing"
+)
+
+// tempPath is a package variable that any function may assign
+var tempPath = "file"
+
+// TestReassignedArg shows no fix is offered when an argument is assigned after the defer,
+// since the closure would read the new value
+func TestReassignedArg(t *testing.T) {
+	name := "a"
+	defer os.Remove(name) // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestReassignedArg\""
+	name = "b"
+	_ = name
+}
+
+// TestReassignedReceiver shows no fix is offered when the receiver is assigned after the defer
+func TestReassignedReceiver(t *testing.T) {
+	f, _ := os.Open("a")
+	defer f.Close() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestReassignedReceiver\""
+	f, _ = os.Open("b")
+	_ = f
+}
+
+// TestReassignedBefore shows assignments before the defer do not matter
+func TestReassignedBefore(t *testing.T) {
+	name := "a"
+	name = "b"
+	defer os.Remove(name) // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestReassignedBefore\""
+}
+
+// TestReassignedInLoop shows assignments before the defer in a loop follow the defers of
+// earlier iterations
+func TestReassignedInLoop(t *testing.T) {
+	var name string
+	for _, n := range []string{"a", "b"} {
+		name = n
+		defer os.Remove(name) // want "use t.Cleanup\\(\\) instead of defer in a loop in test functions; deferred calls accumulate until the test function returns instead of running per iteration, and are skipped after t.Fatal/t.FailNow in test function \"TestReassignedInLoop\""
+	}
+}
+
+// TestRangeVar shows range variables are declared per iteration since Go 1.22
+func TestRangeVar(t *testing.T) {
+	for _, n := range []string{"a", "b"} {
+		defer os.Remove(n) // want "use t.Cleanup\\(\\) instead of defer in a loop in test functions; deferred calls accumulate until the test function returns instead of running per iteration, and are skipped after t.Fatal/t.FailNow in test function \"TestRangeVar\""
+	}
+}
+
+// TestPackageVarArg shows no fix is offered for package variables
+func TestPackageVarArg(t *testing.T) {
+	defer os.Remove(tempPath) // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestPackageVarArg\""
+}
+
+// TestAddressTaken shows no fix is offered for variables that may be assigned through a pointer
+func TestAddressTaken(t *testing.T) {
+	name := "a"
+	defer os.Remove(name) // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestAddressTaken\""
+	rename(&name)
+}
+
+func rename(name *string) { *name = "b" }
+
+// TestReassignedMethodExpr shows no fix is offered when the receiver passed to a method
+// expression is assigned after the defer
+func TestReassignedMethodExpr(t *testing.T) {
+	srv := &server{}
+	defer (*server).Close(srv) // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestReassignedMethodExpr\""
+	srv = nil
+	_ = srv
+}
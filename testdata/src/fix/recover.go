@@ -0,0 +1,14 @@
+package fix
+
+import "testing"
+
+// TestRecoverWithCleanup shows no fix is offered for a deferred function that also
+// recovers, since recover() returns nil in a function registered with t.Cleanup
+func TestRecoverWithCleanup(t *testing.T) {
+	defer func() { // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestRecoverWithCleanup\""
+		if r := recover(); r != nil {
+			t.Errorf("recovered: %v", r)
+		}
+		cleanup()
+	}()
+}
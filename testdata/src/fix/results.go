@@ -0,0 +1,12 @@
+package fix
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestNamedResult shows no fix is offered when the deferred function sets a named result
+func TestNamedResult(t *testing.T) (err error) {
+	defer func() { err = errors.New("failed") }() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow, but set the named result \"err\" before returning; the deferred function assigns it, which a function registered with t.Cleanup cannot do in test function \"TestNamedResult\""
+	return nil
+}
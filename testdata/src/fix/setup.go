@@ -0,0 +1,12 @@
+package fix
+
+import "testing"
+
+func setupTest(t *testing.T) func() {
+	return func() {}
+}
+
+// TestDeferredSetup shows the returned teardown is registered as is
+func TestDeferredSetup(t *testing.T) {
+	defer setupTest(t)() // want "use t.Cleanup\\(setupTest\\(t\\)\\) instead of deferring the teardown returned by a setup call, or better, register the teardown with t.Cleanup inside the setup function; a deferred teardown is skipped after t.Fatal/t.FailNow in test function \"TestDeferredSetup\""
+}
@@ -0,0 +1,16 @@
+package fix
+
+import "testing"
+
+// TestShadowedTable shows the fix uses the subtest's parameter in table tests
+func TestShadowedTable(t *testing.T) {
+	cases := []struct{ name string }{{"a"}, {"b"}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(subT *testing.T) {
+			defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestShadowedTable\""
+		})
+		t.Run(tt.name, func(t *testing.T) {
+			defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestShadowedTable\""
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// Package ginkgo is a stub of github.com/onsi/ginkgo/v2.
+package ginkgo
+
+import "testing"
+
+func RunSpecs(t *testing.T, description string) bool { return true }
+
+func Describe(text string, args ...any) bool { return true }
+
+func Context(text string, args ...any) bool { return true }
+
+func It(text string, args ...any) bool { return true }
+
+func Specify(text string, args ...any) bool { return true }
+
+func BeforeEach(args ...any) bool { return true }
+
+func AfterEach(args ...any) bool { return true }
+
+func JustBeforeEach(args ...any) bool { return true }
+
+func JustAfterEach(args ...any) bool { return true }
+
+func BeforeAll(args ...any) bool { return true }
+
+func AfterAll(args ...any) bool { return true }
+
+func BeforeSuite(body any, args ...any) bool { return true }
+
+func AfterSuite(body any, args ...any) bool { return true }
+
+func DeferCleanup(args ...any) {}
+
+func Fail(message string, callerSkip ...int) {}
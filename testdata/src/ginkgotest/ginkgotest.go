@@ -0,0 +1,43 @@
+package ginkgotest
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+)
+
+func cleanup() {}
+
+func TestSuite(t *testing.T) {
+	RunSpecs(t, "suite")
+}
+
+var _ = Describe("a server", func() {
+	defer cleanup() // Containers run while building the spec tree, not as part of a spec
+
+	BeforeEach(func() {
+		defer cleanup() // want "use DeferCleanup\\(\\) instead of defer in Ginkgo nodes to ensure cleanup runs even after a failed assertion stops the node in Ginkgo node \"BeforeEach\""
+	})
+
+	AfterEach(func() {
+		defer cleanup() // want "use DeferCleanup\\(\\) instead of defer in Ginkgo nodes to ensure cleanup runs even after a failed assertion stops the node in Ginkgo node \"AfterEach\""
+	})
+
+	Context("when started", func() {
+		It("serves requests", func() {
+			defer cleanup() // want "use DeferCleanup\\(\\) instead of defer in Ginkgo nodes to ensure cleanup runs even after a failed assertion stops the node in Ginkgo node \"serves requests\""
+
+			func() {
+				defer cleanup() // Plain literals run in their own frame
+			}()
+		})
+
+		It("uses DeferCleanup", func() {
+			DeferCleanup(cleanup)
+		})
+	})
+})
+
+var _ = BeforeSuite(func() {
+	defer cleanup() // want "use DeferCleanup\\(\\) instead of defer in Ginkgo nodes to ensure cleanup runs even after a failed assertion stops the node in Ginkgo node \"BeforeSuite\""
+})
@@ -0,0 +1,7 @@
+module ginkgotest
+
+go 1.25.1
+
+require github.com/onsi/ginkgo/v2 v2.0.0
+
+replace github.com/onsi/ginkgo/v2 => ../ginkgo
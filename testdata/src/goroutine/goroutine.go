@@ -0,0 +1,19 @@
+package goroutine
+
+import "testing"
+
+// TestDeferWithGoroutineAndTestingT shows goroutine with *testing.T parameter
+func TestDeferWithGoroutineAndTestingT(t *testing.T) {
+	go func(t *testing.T) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferWithGoroutineAndTestingT\""
+	}(t)
+}
+
+// TestDeferInGoroutine shows goroutines without *testing.T are still not checked
+func TestDeferInGoroutine(t *testing.T) {
+	go func() {
+		defer cleanup() // No warning - no *testing.T parameter
+	}()
+}
+
+func cleanup() {}
@@ -0,0 +1,3 @@
+module helperfacts
+
+go 1.25.1
@@ -0,0 +1,47 @@
+package helperfacts
+
+import (
+	"testing"
+
+	"helperfacts/lib"
+)
+
+// mustSetup defers a call and may stop the test via t.FailNow
+func mustSetup(t *testing.T) { // want mustSetup:"deferringHelper\\(2\\)" mustSetup:"stoppingHelper"
+	defer teardown()
+	defer teardown()
+	t.FailNow()
+}
+
+func TestCallsHelpers(t *testing.T) {
+	lib.MustOpen(t, "name") // want "helper \"MustOpen\" defers calls that are skipped if it stops the test early; use t.Cleanup\\(\\) in the helper in test function \"TestCallsHelpers\""
+	mustSetup(t)            // want "helper \"mustSetup\" defers calls that are skipped if it stops the test early; use t.Cleanup\\(\\) in the helper in test function \"TestCallsHelpers\""
+
+	lib.Open(t)            // No warning - Open never stops early
+	lib.MustCreate(t)      // No warning - MustCreate does not defer
+	lib.MustOpenIgnored(t) // No warning - the defer is suppressed
+
+	t.Run("sub", func(t *testing.T) {
+		mustSetup(t) // want "helper \"mustSetup\" defers calls that are skipped if it stops the test early; use t.Cleanup\\(\\) in the helper in test function \"TestCallsHelpers\""
+	})
+
+	mustSetup(t) //nodefertest:ignore
+}
+
+// TestDeferredFixture shows the teardowns returned by helpers being deferred
+func TestDeferredFixture(t *testing.T) {
+	defer lib.NewFixture(t)()          // want "use t.Cleanup\\(lib.NewFixture\\(t\\)\\) instead of deferring the teardown returned by a setup call, or better, register the teardown with t.Cleanup inside the setup function; a deferred teardown is skipped after t.Fatal/t.FailNow in test function \"TestDeferredFixture\""
+	defer lib.NewFixtureDeferring(t)() // want "use t.Cleanup\\(lib.NewFixtureDeferring\\(t\\)\\) instead of deferring the teardown returned by a setup call, or better, register the teardown with t.Cleanup inside the setup function; a deferred teardown is skipped after t.Fatal/t.FailNow in test function \"TestDeferredFixture\"" "helper \"NewFixtureDeferring\" defers calls that are skipped if it stops the test early; use t.Cleanup\\(\\) in the helper in test function \"TestDeferredFixture\""
+}
+
+// TestRegisteredFixture shows the teardown returned by a helper registered with t.Cleanup
+func TestRegisteredFixture(t *testing.T) {
+	t.Cleanup(lib.NewFixture(t)) // No warning - the teardown is registered with t.Cleanup
+}
+
+// helperCallsHelper is not a test function, so its calls are not reported
+func helperCallsHelper(t *testing.T) { // want helperCallsHelper:"stoppingHelper"
+	mustSetup(t)
+}
+
+func teardown() {}
@@ -0,0 +1,47 @@
+// Package lib provides test helpers used across packages.
+package lib
+
+import "testing"
+
+// MustOpen defers a call and may stop the test via t.Fatal
+func MustOpen(t *testing.T, name string) { // want MustOpen:"deferringHelper\\(1\\)" MustOpen:"stoppingHelper"
+	t.Helper()
+	defer Close()
+
+	if name == "" {
+		t.Fatal("no name")
+	}
+}
+
+// Open defers a call but never stops the test early
+func Open(t *testing.T) {
+	defer Close()
+	t.Log("opened")
+}
+
+// MustCreate stops the test early but does not defer
+func MustCreate(t *testing.T) { // want MustCreate:"stoppingHelper"
+	t.Fatal("not implemented")
+}
+
+// MustOpenIgnored has its defer suppressed by an ignore directive
+func MustOpenIgnored(t *testing.T) { // want MustOpenIgnored:"stoppingHelper"
+	defer Close() //nodefertest:ignore
+	t.FailNow()
+}
+
+// NewFixture returns the teardown of the fixture for the caller to register
+func NewFixture(t *testing.T) func() {
+	return Close
+}
+
+// NewFixtureDeferring returns a teardown, and also defers a call and may stop the test via t.Fatal
+func NewFixtureDeferring(t *testing.T) func() { // want NewFixtureDeferring:"deferringHelper\\(1\\)" NewFixtureDeferring:"stoppingHelper"
+	defer Close()
+	if t.Failed() {
+		t.Fatal("failed")
+	}
+	return Close
+}
+
+func Close() {}
@@ -0,0 +1,29 @@
+package helpers
+
+import "testing"
+
+// assertEqual is a generic helper receiving *testing.T
+func assertEqual[T comparable](t *testing.T, got, want T) { // want assertEqual:"deferringHelper\\(1\\)" assertEqual:"stoppingHelper"
+	t.Helper()
+	defer teardown() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"assertEqual\""
+
+	if got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+// assertPair is a generic helper with several type parameters
+func assertPair[K comparable, V any](tb testing.TB, k K, v V) {
+	defer teardown() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"assertPair\""
+}
+
+// TestGeneric is a generic function with a test name, only run through wrappers
+func TestGeneric[T any](t *testing.T) {
+	defer teardown() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestGeneric\""
+}
+
+func TestUsesGenericHelper(t *testing.T) {
+	assertEqual(t, 1, 1)             // want "helper \"assertEqual\" defers calls that are skipped if it stops the test early; use t.Cleanup\\(\\) in the helper in test function \"TestUsesGenericHelper\""
+	assertEqual[string](t, "a", "a") // want "helper \"assertEqual\" defers calls that are skipped if it stops the test early; use t.Cleanup\\(\\) in the helper in test function \"TestUsesGenericHelper\""
+	assertPair(t, "k", 1)
+}
@@ -0,0 +1,42 @@
+package helpers
+
+import "testing"
+
+// setup is a helper receiving *testing.T
+func setup(t *testing.T) { // want setup:"deferringHelper\\(1\\)" setup:"stoppingHelper"
+	t.Helper()
+	defer teardown() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"setup\""
+
+	t.Fatal("setup failed")
+}
+
+// benchSetup is a helper receiving *testing.B
+func benchSetup(b *testing.B) {
+	defer teardown() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"benchSetup\""
+}
+
+// withSubtest is a helper whose subtest closure is also checked
+func withSubtest(t *testing.T) {
+	t.Run("sub", func(t *testing.T) {
+		defer teardown() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"withSubtest\""
+	})
+}
+
+// noTestingParam is not a helper
+func noTestingParam() {
+	defer teardown() // No warning - no testing parameter
+}
+
+func TestUsesHelper(t *testing.T) {
+	defer teardown() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestUsesHelper\""
+
+	setup(t) // want "helper \"setup\" defers calls that are skipped if it stops the test early; use t.Cleanup\\(\\) in the helper in test function \"TestUsesHelper\""
+}
+
+func teardown() {}
+
+// check is a helper receiving testing.TB
+func check(tb testing.TB) {
+	tb.Helper()
+	defer teardown() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"check\""
+}
@@ -0,0 +1,32 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// openResource is a helper whose deferred function sets its named result
+func openResource(t *testing.T) (err error) {
+	defer func() { // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow, but set the named result \"err\" before returning; the deferred function assigns it, which a function registered with t.Cleanup cannot do in test function \"openResource\""
+		if err != nil {
+			err = fmt.Errorf("open: %w", err)
+		}
+	}()
+	return nil
+}
+
+// countRetries is a helper whose deferred function increments its named result
+func countRetries(t *testing.T) (n int) {
+	defer func() { n++ }() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow, but set the named result \"n\" before returning; the deferred function assigns it, which a function registered with t.Cleanup cannot do in test function \"countRetries\""
+	return 0
+}
+
+// shadowedResult is a helper whose deferred function only assigns a local variable
+func shadowedResult(t *testing.T) (err error) {
+	defer func() { // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"shadowedResult\""
+		err := errors.New("local")
+		_ = err
+	}()
+	return nil
+}
@@ -0,0 +1,3 @@
+module intentional
+
+go 1.25.1
@@ -0,0 +1,32 @@
+package intentional
+
+import (
+	"os"
+	"testing"
+)
+
+func cleanup() {}
+
+// TestIntentionalComment shows a defer explained by a comment right above it is allowed
+func TestIntentionalComment(t *testing.T) {
+	old := os.Getenv("HOME")
+	// intentional defer: restores env before return
+	defer os.Setenv("HOME", old)
+
+	// Intentional: the explanation may span
+	// several lines
+	defer cleanup()
+}
+
+// TestOtherComment shows other comments do not allow the defer
+func TestOtherComment(t *testing.T) {
+	// restore the environment
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestOtherComment\""
+
+	// intentional defer: separated by a blank line
+
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestOtherComment\""
+
+	cleanup()       // intentional: a trailing comment explains its own line
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestOtherComment\""
+}
@@ -0,0 +1,19 @@
+package loopvar
+
+import "testing"
+
+// TestCapturedLoopVar shows deferred function literals capturing a loop variable that
+// all iterations share before Go 1.22
+func TestCapturedLoopVar(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		defer func() { // want "use t.Cleanup\\(\\) instead of defer in a loop in test functions; deferred calls accumulate until the test function returns instead of running per iteration, and are skipped after t.Fatal/t.FailNow; the deferred function captures the loop variable \"i\", which before Go 1.22 is shared by all iterations in test function \"TestCapturedLoopVar\""
+			t.Log(i)
+		}()
+	}
+
+	for _, name := range []string{"a", "b"} {
+		defer func() { // want "use t.Cleanup\\(\\) instead of defer in a loop in test functions; deferred calls accumulate until the test function returns instead of running per iteration, and are skipped after t.Fatal/t.FailNow; the deferred function captures the loop variable \"name\", which before Go 1.22 is shared by all iterations in test function \"TestCapturedLoopVar\""
+			t.Log(name)
+		}()
+	}
+}
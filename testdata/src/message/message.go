@@ -0,0 +1,13 @@
+package message
+
+import "testing"
+
+func cleanup() {}
+
+func TestMessage(t *testing.T) {
+	defer cleanup() // want "TestMessage: use t.Cleanup, see https://wiki.example.com/defer"
+}
+
+func TestNamedParam(tb *testing.T) {
+	defer cleanup() // want "TestNamedParam: use tb.Cleanup, see https://wiki.example.com/defer"
+}
@@ -0,0 +1,28 @@
+package nesting
+
+import "testing"
+
+func cleanup() {}
+
+// TestNesting nests every kind of checked function literal in one another
+func TestNesting(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestNesting\""
+	t.Run("a", func(t *testing.T) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestNesting\""
+		t.Run("b", func(t *testing.T) {
+			t.Cleanup(func() {
+				defer cleanup() // want "avoid defer in functions registered with t.Cleanup; call it at the end of the cleanup function, or register it with its own t.Cleanup, which runs after this one, in test function \"TestNesting\""
+			})
+			check := func(tb testing.TB) {
+				defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestNesting\""
+			}
+			check(t)
+		})
+	})
+	tests := []func(*testing.T){
+		func(t *testing.T) {
+			defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestNesting\""
+		},
+	}
+	_ = tests
+}
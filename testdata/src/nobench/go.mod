@@ -0,0 +1,3 @@
+module nobench
+
+go 1.25.1
@@ -0,0 +1,19 @@
+package nobench
+
+import "testing"
+
+// TestWithDefer is still checked when benchmarks are skipped
+func TestWithDefer(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestWithDefer\""
+}
+
+// BenchmarkWithDefer is ignored when benchmarks are skipped
+func BenchmarkWithDefer(b *testing.B) {
+	defer cleanup() // No warning - -check-benchmarks=false
+
+	b.Run("sub", func(b *testing.B) {
+		defer cleanup() // No warning - -check-benchmarks=false
+	})
+}
+
+func cleanup() {}
@@ -0,0 +1,15 @@
+package oldgo
+
+import "testing"
+
+func cleanup() {}
+
+// TestOldGo shows no fix is suggested before Go 1.14, which has no t.Cleanup
+func TestOldGo(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestOldGo\""
+}
+
+// BenchmarkOldGo shows no fix is suggested for b.Cleanup either
+func BenchmarkOldGo(b *testing.B) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"BenchmarkOldGo\""
+}
@@ -0,0 +1,16 @@
+package onlytest
+
+import "testing"
+
+func cleanup() {}
+
+// TestConnection is not run by go test outside of a _test.go file
+func TestConnection(t *testing.T) {
+	defer cleanup()
+}
+
+// setup is a shared helper, which is still marked outside of _test.go files
+func setup(t *testing.T) { // want setup:"deferringHelper\\(1\\)" setup:"stoppingHelper"
+	defer cleanup()
+	t.Fatal("setup failed")
+}
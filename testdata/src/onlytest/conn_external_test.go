@@ -0,0 +1,7 @@
+package onlytest_test
+
+import "testing"
+
+func TestConnectionExternal(t *testing.T) {
+	defer t.Log("closed") // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestConnectionExternal\""
+}
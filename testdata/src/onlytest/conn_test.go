@@ -0,0 +1,9 @@
+package onlytest
+
+import "testing"
+
+func TestConnectionReal(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestConnectionReal\""
+
+	setup(t) // want "helper \"setup\" defers calls that are skipped if it stops the test early; use t.Cleanup\\(\\) in the helper in test function \"TestConnectionReal\""
+}
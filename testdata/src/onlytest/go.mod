@@ -0,0 +1,3 @@
+module onlytest
+
+go 1.25.1
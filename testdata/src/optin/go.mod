@@ -0,0 +1,3 @@
+module optin
+
+go 1.25.1
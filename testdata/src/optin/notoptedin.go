@@ -0,0 +1,9 @@
+package optin
+
+import "testing"
+
+// The marker only counts as a comment of its own: //go:nodefertest
+
+func TestNotOptedIn(t *testing.T) {
+	defer cleanup()
+}
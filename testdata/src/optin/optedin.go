@@ -0,0 +1,11 @@
+//go:nodefertest
+
+package optin
+
+import "testing"
+
+func cleanup() {}
+
+func TestOptedIn(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestOptedIn\""
+}
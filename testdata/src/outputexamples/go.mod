@@ -0,0 +1,3 @@
+module outputexamples
+
+go 1.25.1
@@ -0,0 +1,40 @@
+package outputexamples
+
+import "fmt"
+
+func cleanup() {}
+
+// ExampleWithOutput is a runnable example, where the defer is part of the documentation
+func ExampleWithOutput() {
+	defer cleanup()
+
+	fmt.Println("done")
+	// Output: done
+}
+
+// ExampleWithUnorderedOutput is a runnable example with unordered output
+func ExampleWithUnorderedOutput() {
+	defer cleanup()
+
+	fmt.Println("a")
+	fmt.Println("b")
+	// Unordered output:
+	// b
+	// a
+}
+
+// ExampleWithoutOutput is compiled but not run, so it is still checked
+func ExampleWithoutOutput() {
+	defer cleanup() // want "avoid defer in example functions; examples run as part of the test binary and deferred calls are skipped if the example exits early in example function \"ExampleWithoutOutput\""
+
+	fmt.Println("done")
+}
+
+// ExampleOutputNotLast only has an output comment before other comments, which go test ignores
+func ExampleOutputNotLast() {
+	defer cleanup() // want "avoid defer in example functions; examples run as part of the test binary and deferred calls are skipped if the example exits early in example function \"ExampleOutputNotLast\""
+
+	// Output: done
+	fmt.Println("done")
+	// The output is not checked
+}
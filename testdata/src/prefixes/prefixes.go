@@ -0,0 +1,25 @@
+package prefixes
+
+import "testing"
+
+// IntegrationTestDatabase is discovered by a custom test runner
+func IntegrationTestDatabase(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"IntegrationTestDatabase\""
+}
+
+// AcceptanceTest_login is discovered by a custom test runner
+func AcceptanceTest_login(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"AcceptanceTest_login\""
+}
+
+// IntegrationTestable does not follow the naming convention
+func IntegrationTestable(t *testing.T) {
+	defer cleanup() // No warning - lowercase rune after the prefix
+}
+
+// TestNotInPrefixes is not discovered since "Test" is not in -prefixes
+func TestNotInPrefixes(t *testing.T) {
+	defer cleanup() // No warning - "Test" is not a configured prefix
+}
+
+func cleanup() {}
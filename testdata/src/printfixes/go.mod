@@ -0,0 +1,3 @@
+module printfixes
+
+go 1.25.1
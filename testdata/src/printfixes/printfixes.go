@@ -0,0 +1,9 @@
+package printfixes
+
+import "testing"
+
+func cleanup() {}
+
+func TestPrintFixes(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestPrintFixes\""
+}
@@ -0,0 +1,16 @@
+package related
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRelated(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestRelated\""
+	defer func() {  // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestRelated\""
+		cleanup()
+	}()
+	defer os.Remove("file") // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestRelated\""
+}
+
+func cleanup() {}
@@ -0,0 +1,3 @@
+module requirefatal
+
+go 1.25.1
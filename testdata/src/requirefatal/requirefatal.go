@@ -0,0 +1,119 @@
+package requirefatal
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+// TestOnlyLog never stops early, so its defer is safe
+func TestOnlyLog(t *testing.T) {
+	defer cleanup() // No warning - nothing can skip the defer
+
+	t.Log("running")
+	t.Error("failed but keeps running")
+}
+
+// TestFatal may stop early via t.Fatal
+func TestFatal(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestFatal\""
+
+	if someCondition() {
+		t.Fatal("failed")
+	}
+}
+
+// TestFailNowInClosure may stop early via t.FailNow in a closure
+func TestFailNowInClosure(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestFailNowInClosure\""
+
+	check := func() {
+		t.FailNow()
+	}
+	check()
+}
+
+// TestSkip may stop early via t.Skip
+func TestSkip(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestSkip\""
+
+	t.Skip("skipped")
+}
+
+// TestGoexit may stop early via runtime.Goexit
+func TestGoexit(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestGoexit\""
+
+	runtime.Goexit()
+}
+
+// TestHelper passes t to a helper which may call t.Fatal
+func TestHelper(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestHelper\""
+
+	mustSetup(t)
+}
+
+// TestPassesT passes t to functions that never stop the test
+func TestPassesT(t *testing.T) {
+	defer cleanup() // No warning - printing or logging t does not stop the test
+
+	fmt.Println(t)
+	logName(t)
+}
+
+// TestHelperCallsHelper passes t to a helper whose own helper may call t.Fatal
+func TestHelperCallsHelper(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestHelperCallsHelper\""
+
+	setupAll(t)
+}
+
+// TestFatalOnlyInSubtest only stops the subtest, not the outer test
+func TestFatalOnlyInSubtest(t *testing.T) {
+	defer cleanup() // No warning - t.Fatal in the subtest does not skip this defer
+
+	t.Run("sub", func(t *testing.T) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestFatalOnlyInSubtest\""
+
+		t.Fatalf("failed: %d", 1)
+	})
+}
+
+// BenchmarkFatal may stop early via b.Fatal
+func BenchmarkFatal(b *testing.B) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"BenchmarkFatal\""
+
+	b.Fatal("failed")
+}
+
+func mustSetup(t *testing.T) { // want mustSetup:"stoppingHelper"
+	t.Helper()
+	t.Fatal("setup failed")
+}
+
+// setupAll is declared before the helper it calls, which may call t.Fatal
+func setupAll(t *testing.T) { // want setupAll:"stoppingHelper"
+	mustSetup(t)
+}
+
+// logName receives t but never stops the test
+func logName(t *testing.T) {
+	t.Log(t.Name())
+}
+
+func cleanup() {}
+
+func someCondition() bool { return false }
+
+// TestFatalAfterDefers only calls t.Fatal once the deferred calls run, which it cannot skip
+func TestFatalAfterDefers(t *testing.T) { // want "defer and t.Cleanup are mixed; deferred calls run when the function returns, before the functions registered with t.Cleanup, which run after the test and its subtests have completed, in test function \"TestFatalAfterDefers\""
+	defer cleanup() // No warning - the t.Fatal calls below run after the defer
+
+	t.Cleanup(func() {
+		t.Fatal("in a cleanup") // want "avoid t.Fatal/t.FailNow in functions registered with t.Cleanup; it skips the rest of the cleanup function, so report with t.Error and continue the cleanup, in test function \"TestFatalAfterDefers\""
+	})
+	defer func() {
+		t.Fatal("in a deferred call")
+	}()
+}
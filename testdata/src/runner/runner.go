@@ -0,0 +1,47 @@
+package runner
+
+import "testing"
+
+func cleanup() {}
+
+// Runner is run by a framework that calls Run as the body of each test
+type Runner struct{}
+
+// Run is checked under -method-names=Run, like a test function
+func (r Runner) Run(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"Run\""
+}
+
+// PtrRunner is a runner used by pointer
+type PtrRunner struct{}
+
+// Run is checked with a pointer receiver too
+func (r *PtrRunner) Run(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"Run\""
+}
+
+// BenchRunner runs benchmarks
+type BenchRunner struct{}
+
+// Run is checked with a *testing.B parameter too
+func (BenchRunner) Run(b *testing.B) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"Run\""
+}
+
+// Start is not one of -method-names
+func (r Runner) Start(t *testing.T) {
+	defer cleanup()
+}
+
+// Run is not a method, so it is not checked
+func Run(t *testing.T) {
+	defer cleanup()
+}
+
+// Report takes no testing parameter
+type Report struct{}
+
+// Run is not checked without a testing parameter
+func (Report) Run() {
+	defer cleanup()
+}
@@ -0,0 +1,9 @@
+package severity
+
+import "testing"
+
+func TestSeverity(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestSeverity\""
+}
+
+func cleanup() {}
@@ -0,0 +1,3 @@
+module sharedgo
+
+go 1.25.1
@@ -0,0 +1,87 @@
+package sharedgo
+
+import (
+	"sync"
+	"testing"
+)
+
+type conn struct{}
+
+func (c *conn) Close() error { return nil }
+
+func (c *conn) Read() {}
+
+func dial() *conn { return &conn{} }
+
+func closeConn(c *conn) {}
+
+func cleanup() {}
+
+// TestGoroutineUsesConn shows a goroutine still reading the connection the defer closes
+func TestGoroutineUsesConn(t *testing.T) {
+	conn := dial()
+	defer conn.Close() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow, and wait for the goroutine started on line 25 to finish before releasing \"conn\", which it still uses; neither the deferred call nor t.Cleanup waits for it in test function \"TestGoroutineUsesConn\""
+
+	go func() {
+		conn.Read()
+	}()
+}
+
+// TestGoroutineGetsConn shows a goroutine receiving the connection the deferred call closes
+func TestGoroutineGetsConn(t *testing.T) {
+	c := dial()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go read(&wg, c)
+	defer closeConn(c) // want "and wait for the goroutine started on line 35 to finish before releasing \"c\", which it still uses; .* in test function \"TestGoroutineGetsConn\""
+	wg.Wait()
+}
+
+func read(wg *sync.WaitGroup, c *conn) {
+	defer wg.Done()
+	c.Read()
+}
+
+// TestGoroutineStopped shows closing a channel to stop a goroutine is not a release
+func TestGoroutineStopped(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done) // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestGoroutineStopped\""
+
+	go func() {
+		<-done
+	}()
+}
+
+// TestGoroutineUsesOther shows a goroutine that does not use what the defer releases
+func TestGoroutineUsesOther(t *testing.T) {
+	a, b := dial(), dial()
+	defer a.Close() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestGoroutineUsesOther\""
+
+	go func() {
+		b.Read()
+	}()
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestGoroutineUsesOther\""
+}
+
+// TestGoroutineInSubtest shows a goroutine of a subtest is not attributed to the parent's defer
+func TestGoroutineInSubtest(t *testing.T) {
+	c := dial()
+	defer c.Close() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestGoroutineInSubtest\""
+
+	t.Run("read", func(t *testing.T) {
+		go func() {
+			c.Read()
+		}()
+	})
+}
+
+// TestGoroutineWaited shows waiting for a goroutine is not a release of what it uses
+func TestGoroutineWaited(t *testing.T) {
+	var wg sync.WaitGroup
+	defer wg.Wait() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestGoroutineWaited\""
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+	}()
+}
@@ -0,0 +1,3 @@
+module shortguard
+
+go 1.25.1
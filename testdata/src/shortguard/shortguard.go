@@ -0,0 +1,54 @@
+package shortguard
+
+import "testing"
+
+func cleanup() {}
+
+// TestLongModeBranch shows a defer in a branch keyed on testing.Short()
+func TestLongModeBranch(t *testing.T) {
+	if !testing.Short() {
+		defer cleanup()
+	}
+	if testing.Short() {
+		t.Log("short")
+	} else {
+		defer cleanup()
+	}
+
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestLongModeBranch\""
+}
+
+// TestAfterShortGuard shows defers after a guard that leaves the test in short mode
+func TestAfterShortGuard(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestAfterShortGuard\""
+
+	if testing.Short() {
+		t.Skip("long test")
+	}
+	defer cleanup()
+}
+
+// TestAfterShortReturn shows a guard returning early
+func TestAfterShortReturn(t *testing.T) {
+	if testing.Short() {
+		return
+	}
+	defer cleanup()
+}
+
+// TestNotAGuard shows an if statement on testing.Short() that does not leave the test
+func TestNotAGuard(t *testing.T) {
+	if testing.Short() {
+		t.Log("short")
+	}
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestNotAGuard\""
+}
+
+// TestShortGuardWithCleanup shows defers left as is in long mode are not mixed with t.Cleanup
+func TestShortGuardWithCleanup(t *testing.T) {
+	t.Cleanup(cleanup)
+	if testing.Short() {
+		t.Skip("long test")
+	}
+	defer cleanup()
+}
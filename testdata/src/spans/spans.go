@@ -0,0 +1,27 @@
+package spans
+
+import (
+	"context"
+	"runtime/trace"
+	"testing"
+)
+
+func cleanup() {}
+
+type span struct{}
+
+func (span) End() {}
+
+func (span) Finish() {}
+
+func start(ctx context.Context, name string) (context.Context, span) { return ctx, span{} }
+
+// TestSpanEnd shows deferred span and region ends are allowed under -allow-span-end
+func TestSpanEnd(t *testing.T) {
+	ctx, sp := start(context.Background(), "test")
+	defer sp.End()
+	defer trace.StartRegion(ctx, "region").End()
+
+	defer sp.Finish() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestSpanEnd\""
+	defer cleanup()   // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestSpanEnd\""
+}
@@ -0,0 +1,3 @@
+module stoprelated
+
+go 1.25.1
@@ -0,0 +1,31 @@
+package stoprelated
+
+import "testing"
+
+func cleanup() {}
+
+func TestStopRelated(t *testing.T) {
+	t.Fatal("before the defer")
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestStopRelated\""
+	t.Run("sub", func(t *testing.T) {
+		t.Fatal("in a subtest")
+	})
+	if testing.Short() {
+		t.Fatal("short")
+	}
+	t.FailNow()
+}
+
+func TestNoStop(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestNoStop\""
+}
+
+func TestStopAfterDefers(t *testing.T) { // want "defer and t.Cleanup are mixed; deferred calls run when the function returns, before the functions registered with t.Cleanup, which run after the test and its subtests have completed, in test function \"TestStopAfterDefers\""
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestStopAfterDefers\""
+	t.Cleanup(func() {
+		t.Fatal("in a cleanup") // want "avoid t.Fatal/t.FailNow in functions registered with t.Cleanup; it skips the rest of the cleanup function, so report with t.Error and continue the cleanup, in test function \"TestStopAfterDefers\""
+	})
+	defer func() { // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestStopAfterDefers\""
+		t.Fatal("in a deferred call")
+	}()
+}
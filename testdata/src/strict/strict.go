@@ -0,0 +1,26 @@
+package strict
+
+import "testing"
+
+func cleanup() {}
+
+// TestReal has the signature of a test run by go test
+func TestReal(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestReal\""
+}
+
+// TestParameterized is a helper run by a driver, not by go test
+func TestParameterized(t *testing.T, n int) {
+	defer cleanup()
+}
+
+// TestWithResult is not a test run by go test
+func TestWithResult(t *testing.T) error {
+	defer cleanup()
+	return nil
+}
+
+// BenchmarkReal has the signature of a benchmark
+func BenchmarkReal(b *testing.B) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"BenchmarkReal\""
+}
@@ -0,0 +1,7 @@
+module suitetest
+
+go 1.25.1
+
+require github.com/stretchr/testify v1.11.1
+
+replace github.com/stretchr/testify => ../testify
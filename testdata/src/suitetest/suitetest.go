@@ -0,0 +1,82 @@
+package suitetest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MySuite struct {
+	suite.Suite
+}
+
+func TestMySuite(t *testing.T) {
+	suite.Run(t, new(MySuite))
+}
+
+// TestSomething shows defer in a suite test method
+func (s *MySuite) TestSomething() { // want "defer and t.Cleanup are mixed; deferred calls run when the function returns, before the functions registered with t.Cleanup, which run after the test and its subtests have completed, in test function \"TestSomething\""
+	defer s.cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestSomething\""
+
+	s.T().Cleanup(s.cleanup) // No warning - correct approach
+}
+
+// TestValueReceiver shows defer in a suite test method with a value receiver
+func (s MySuite) TestValueReceiver() {
+	defer s.cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestValueReceiver\""
+}
+
+// SetupTest is not a test method
+func (s *MySuite) SetupTest() {
+	defer s.cleanup() // No warning - not a test method
+}
+
+func (s *MySuite) cleanup() {}
+
+type NestedSuite struct {
+	MySuite
+}
+
+// TestNested shows suite.Suite embedded through another suite
+func (s *NestedSuite) TestNested() {
+	defer s.cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestNested\""
+}
+
+type PtrSuite struct {
+	*suite.Suite
+}
+
+// TestPointerEmbedded shows suite.Suite embedded by pointer, with a pointer receiver
+func (s *PtrSuite) TestPointerEmbedded() {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestPointerEmbedded\""
+}
+
+// TestPointerEmbeddedValue shows suite.Suite embedded by pointer, with a value receiver
+func (s PtrSuite) TestPointerEmbeddedValue() {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestPointerEmbeddedValue\""
+}
+
+// TestNestedValue shows suite.Suite embedded through another suite, with a value receiver
+func (s NestedSuite) TestNestedValue() {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestNestedValue\""
+}
+
+type notASuite struct{}
+
+// TestNotASuite is a method on a type that does not embed suite.Suite
+func (n *notASuite) TestNotASuite() {
+	defer cleanup() // No warning - not a suite
+}
+
+type Suite struct{}
+
+type fakeSuite struct {
+	Suite
+}
+
+// TestFakeSuite embeds an unrelated type named Suite
+func (f *fakeSuite) TestFakeSuite() {
+	defer cleanup() // No warning - not testify's suite.Suite
+}
+
+func cleanup() {}
@@ -0,0 +1,18 @@
+package summary // want "nodefertest summary: 3 diagnostics in 2 test functions \\(TestA: 2, TestB: 1\\)"
+
+import "testing"
+
+func cleanup() {}
+
+func TestB(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestB\""
+}
+
+func TestA(t *testing.T) {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestA\""
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestA\""
+}
+
+func TestNone(t *testing.T) {
+	t.Cleanup(cleanup)
+}
@@ -0,0 +1,3 @@
+module tbhelpers
+
+go 1.25.1
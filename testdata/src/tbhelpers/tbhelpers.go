@@ -0,0 +1,25 @@
+package tbhelpers
+
+import "testing"
+
+func teardown() {}
+
+// withTB is a utility shared by tests and benchmarks, skipped under -ignore-tb-helpers
+func withTB(tb testing.TB) {
+	defer teardown()
+}
+
+// withT is still checked, since it only runs in tests
+func withT(t *testing.T) {
+	defer teardown() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"withT\""
+}
+
+// withBoth takes a *testing.T as well, so it is checked
+func withBoth(tb testing.TB, t *testing.T) {
+	defer teardown() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"withBoth\""
+}
+
+// TestWithTB is a test rather than a helper, so it is checked whatever its parameter
+func TestWithTB(tb testing.TB) {
+	defer teardown() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestWithTB\""
+}
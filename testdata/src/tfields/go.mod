@@ -0,0 +1,3 @@
+module tfields
+
+go 1.25.1
@@ -0,0 +1,96 @@
+package tfields
+
+import "testing"
+
+type fixture struct {
+	t  *testing.T
+	db *db
+}
+
+// setup is a fixture method that may stop the test via f.t.Fatal
+func (f *fixture) setup() {
+	defer f.db.reset() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"setup\""
+
+	if f.db == nil {
+		f.t.Fatal("no database")
+	}
+}
+
+// close is a fixture method with a value receiver
+func (f fixture) close() {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"close\""
+}
+
+type ptrFixture struct {
+	t *testing.T
+}
+
+// open is a method with a value receiver whose type is only ever used by pointer
+func (f ptrFixture) open() {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"open\""
+}
+
+type tbFixture struct {
+	tb testing.TB
+}
+
+// run is a fixture method storing testing.TB
+func (f *tbFixture) run() {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"run\""
+}
+
+type embeddedFixture struct {
+	*testing.T
+}
+
+// start is a method of a fixture embedding *testing.T, which reaches it as f.T
+func (f *embeddedFixture) start() {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"start\""
+}
+
+type embeddedTBFixture struct {
+	testing.TB
+}
+
+// stop is a method of a fixture embedding testing.TB
+func (f embeddedTBFixture) stop() {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"stop\""
+}
+
+type nestedFixture struct {
+	embeddedFixture
+	name string
+}
+
+// prepare is a method of a fixture embedding another fixture that embeds *testing.T
+func (f *nestedFixture) prepare() {
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"prepare\""
+}
+
+type cyclicFixture struct {
+	*cyclicFixture
+}
+
+// loop is a method of a type embedding a pointer to itself, without a *testing.T field
+func (c *cyclicFixture) loop() {
+	defer cleanup() // No warning - no *testing.T field
+}
+
+// TestAnonymousStruct shows a closure taking an anonymous struct embedding *testing.T
+func TestAnonymousStruct(t *testing.T) {
+	x := struct{ *testing.T }{t}
+	check := func(x struct{ *testing.T }) {
+		defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestAnonymousStruct\""
+		x.Fatal("failed")
+	}
+	check(x)
+}
+
+// reset is a method on a type without a *testing.T field
+func (d *db) reset() {
+	defer cleanup() // No warning - no *testing.T field
+}
+
+type db struct{}
+
+func cleanup() {}
@@ -0,0 +1,8 @@
+package timers
+
+import "testing"
+
+// BenchmarkTimerControls shows deferred timer controls are reported without -allow-timer-controls
+func BenchmarkTimerControls(b *testing.B) {
+	defer b.StopTimer() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"BenchmarkTimerControls\""
+}
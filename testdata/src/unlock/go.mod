@@ -0,0 +1,3 @@
+module unlock
+
+go 1.25.1
@@ -0,0 +1,13 @@
+package unlock
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestDeferUnlock shows deferred unlocks are flagged with -allow-unlock=false
+func TestDeferUnlock(t *testing.T) {
+	var mu sync.Mutex
+	mu.Lock()
+	defer mu.Unlock() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestDeferUnlock\""
+}
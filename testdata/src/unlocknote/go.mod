@@ -0,0 +1,3 @@
+module unlocknote
+
+go 1.25.1
@@ -0,0 +1,28 @@
+package unlocknote
+
+import (
+	"sync"
+	"testing"
+)
+
+func cleanup() {}
+
+// TestNoteUnlock shows allowed unlocks are noted under -note-unlock
+func TestNoteUnlock(t *testing.T) {
+	var mu sync.Mutex
+	mu.Lock()
+	defer mu.Unlock() // want "deferred unlock is kept: unlocking with t.Cleanup would hold the lock until the test and its subtests have completed, and the lock is released even after t.Fatal/t.FailNow, in test function \"TestNoteUnlock\""
+
+	var rw sync.RWMutex
+	rw.RLock()
+	defer rw.RUnlock() // want "deferred unlock is kept: unlocking with t.Cleanup would hold the lock until the test and its subtests have completed, and the lock is released even after t.Fatal/t.FailNow, in test function \"TestNoteUnlock\""
+
+	defer cleanup() // want "use t.Cleanup\\(\\) instead of defer in test functions to ensure cleanup runs even after t.Fatal/t.FailNow in test function \"TestNoteUnlock\""
+}
+
+// TestNoteUnlockIgnored shows ignored unlocks are not noted
+func TestNoteUnlockIgnored(t *testing.T) {
+	var mu sync.Mutex
+	mu.Lock()
+	defer mu.Unlock() //nodefertest:ignore
+}